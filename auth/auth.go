@@ -0,0 +1,204 @@
+// Package auth — аутентификация по JWT для HTTP и gRPC.
+//
+// Authenticator проверяет подпись токена статическими ключами или ключами из JWKS (файл или URL
+// с кэшированием), валидирует issuer, audience и сроки, и кладёт Claims в context.
+// Ошибки возвращаются единообразно: HTTP 401 (application/problem+json) и gRPC UNAUTHENTICATED.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// defaultAlgorithms — допустимые алгоритмы подписи по умолчанию.
+// Тип ключа проверяется библиотекой, поэтому подмена RS256 → HS256 с публичным ключом невозможна.
+var defaultAlgorithms = []string{
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+	"EdDSA",
+	"HS256", "HS384", "HS512",
+}
+
+// defaultPublicMethods — gRPC методы, доступные без токена всегда (пробы k8s).
+var defaultPublicMethods = []string{"/grpc.health.v1.Health/*"}
+
+// Config — настройки Authenticator.
+type Config struct {
+	// Keys — статические ключи проверки подписи по kid: *rsa.PublicKey, *ecdsa.PublicKey,
+	// ed25519.PublicKey или []byte (HMAC). Ключ с kid "" используется для токенов без kid.
+	Keys map[string]any `secret:"true"`
+	// JWKSFile — путь к JWKS документу. Перечитывается раз в JWKSRefreshInterval.
+	JWKSFile string
+	// JWKSURL — URL JWKS документа IdP (напр. https://idp.example.com/.well-known/jwks.json).
+	JWKSURL string
+	// JWKSRefreshInterval — время жизни кэша JWKS. 0 — 5 минут.
+	// Неизвестный kid вызывает внеочередное обновление (не чаще раза в 30 секунд).
+	JWKSRefreshInterval time.Duration
+	// HTTPClient — клиент для загрузки JWKSURL. nil — http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Issuer — ожидаемый claim "iss". Пустая строка — не проверяется.
+	Issuer string
+	// Audience — ожидаемый claim "aud". Пустая строка — не проверяется.
+	Audience string
+	// Algorithms — допустимые алгоритмы подписи. nil — RS*, PS*, ES*, EdDSA, HS*.
+	Algorithms []string
+	// Leeway — допуск расхождения часов при проверке exp/nbf/iat.
+	Leeway time.Duration
+
+	// PublicMethods — gRPC методы без аутентификации: полное имя ("/pkg.Service/Method")
+	// или префикс со звёздочкой ("/pkg.Service/*"). Health доступен всегда.
+	PublicMethods []string
+	// PublicPaths — HTTP пути без аутентификации: точный путь или префикс со звёздочкой ("/v1/public/*").
+	PublicPaths []string
+}
+
+func (c Config) jwksRefreshInterval() time.Duration {
+	if c.JWKSRefreshInterval > 0 {
+		return c.JWKSRefreshInterval
+	}
+	return defaultJWKSRefreshInterval
+}
+
+func (c Config) algorithms() []string {
+	if len(c.Algorithms) > 0 {
+		return c.Algorithms
+	}
+	return defaultAlgorithms
+}
+
+// Validate проверяет, что задан хотя бы один источник ключей.
+func (c Config) Validate() error {
+	var errs []error
+	if len(c.Keys) == 0 && c.JWKSFile == "" && c.JWKSURL == "" {
+		errs = append(errs, errors.New("one of Keys, JWKSFile or JWKSURL is required"))
+	}
+	if c.JWKSFile != "" && c.JWKSURL != "" {
+		errs = append(errs, errors.New("JWKSFile and JWKSURL are mutually exclusive"))
+	}
+	return errors.Join(errs...)
+}
+
+// ErrUnauthenticated — базовая ошибка проверки токена, все ошибки Verify оборачивают её.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Authenticator проверяет JWT и предоставляет HTTP middleware и gRPC interceptors.
+type Authenticator struct {
+	cfg    Config
+	parser *jwt.Parser
+	jwks   *jwksSource
+	log    *slog.Logger
+}
+
+// New создаёт Authenticator. JWKS загружается сразу, чтобы ошибка конфигурации была видна при старте.
+// log nil — slog.Default().
+func New(ctx context.Context, cfg Config, log *slog.Logger) (*Authenticator, error) {
+	if log == nil {
+		log = slog.Default()
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("auth config: %w", err)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(cfg.algorithms()),
+		jwt.WithLeeway(cfg.Leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	a := &Authenticator{
+		cfg:    cfg,
+		parser: jwt.NewParser(opts...),
+		log:    log,
+	}
+
+	if cfg.JWKSFile != "" || cfg.JWKSURL != "" {
+		client := cfg.HTTPClient
+		if client == nil {
+			client = http.DefaultClient
+		}
+		a.jwks = &jwksSource{
+			file:     cfg.JWKSFile,
+			url:      cfg.JWKSURL,
+			client:   client,
+			interval: cfg.jwksRefreshInterval(),
+			log:      log,
+		}
+		if err := a.jwks.load(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// Verify проверяет токен и возвращает его claims.
+func (a *Authenticator) Verify(ctx context.Context, token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := a.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		return a.key(ctx, t)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+	return claims, nil
+}
+
+// key выбирает ключ проверки по kid: сначала статические ключи, затем JWKS.
+// Для токена без kid используется ключ с kid "" или единственный ключ JWKS.
+func (a *Authenticator) key(ctx context.Context, t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+
+	if key, ok := a.cfg.Keys[kid]; ok {
+		return key, nil
+	}
+	if a.jwks != nil {
+		if key, ok := a.jwks.key(ctx, kid); ok {
+			return key, nil
+		}
+		if kid == "" {
+			if key, ok := a.jwks.single(); ok {
+				return key, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// bearerToken извлекает токен из значения "Bearer <token>".
+func bearerToken(authorization string) (string, bool) {
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// matchAny проверяет имя по списку: точное совпадение или префикс для записей со звёздочкой на конце.
+func matchAny(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == p {
+			return true
+		}
+	}
+	return false
+}
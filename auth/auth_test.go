@@ -0,0 +1,418 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// --- helpers ---
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	return key
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return s
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":   "user-1",
+		"iss":   "https://idp.test",
+		"aud":   "orders",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"admin"},
+		"scope": "orders:read orders:write",
+	}
+}
+
+func newAuthenticator(t *testing.T, cfg Config) *Authenticator {
+	t.Helper()
+	a, err := New(context.Background(), cfg, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return a
+}
+
+func ecJWKS(t *testing.T, kid string, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	pub, err := key.PublicKey.ECDH()
+	if err != nil {
+		t.Fatalf("ecdh: %v", err)
+	}
+	raw := pub.Bytes()[1:] // 0x04 || X || Y
+	doc := map[string]any{"keys": []map[string]string{{
+		"kty": "EC",
+		"kid": kid,
+		"use": "sig",
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(raw[:32]),
+		"y":   base64.RawURLEncoding.EncodeToString(raw[32:]),
+	}}}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal jwks: %v", err)
+	}
+	return data
+}
+
+// --- Verify ---
+
+func TestVerify(t *testing.T) {
+	key := newRSAKey(t)
+	other := newRSAKey(t)
+
+	a := newAuthenticator(t, Config{
+		Keys:     map[string]any{"k1": &key.PublicKey},
+		Issuer:   "https://idp.test",
+		Audience: "orders",
+	})
+
+	with := func(k string, v any) jwt.MapClaims {
+		c := validClaims()
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"valid", sign(t, jwt.SigningMethodRS256, key, "k1", validClaims()), false},
+		{"expired", sign(t, jwt.SigningMethodRS256, key, "k1", with("exp", time.Now().Add(-time.Minute).Unix())), true},
+		{"no exp", sign(t, jwt.SigningMethodRS256, key, "k1", with("exp", nil)), true},
+		{"wrong issuer", sign(t, jwt.SigningMethodRS256, key, "k1", with("iss", "https://evil.test")), true},
+		{"wrong audience", sign(t, jwt.SigningMethodRS256, key, "k1", with("aud", "billing")), true},
+		{"unknown kid", sign(t, jwt.SigningMethodRS256, key, "k2", validClaims()), true},
+		{"wrong key", sign(t, jwt.SigningMethodRS256, other, "k1", validClaims()), true},
+		{"alg none", sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "k1", validClaims()), true},
+		{"garbage", "not.a.token", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := a.Verify(context.Background(), tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if claims.Subject != "user-1" || !claims.HasRole("admin") || !claims.HasScope("orders:write") {
+				t.Errorf("unexpected claims: %+v", claims)
+			}
+		})
+	}
+}
+
+// --- JWKS ---
+
+func TestJWKSFile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ec key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, ecJWKS(t, "ec1", key), 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+
+	a := newAuthenticator(t, Config{JWKSFile: path})
+
+	if _, err := a.Verify(context.Background(), sign(t, jwt.SigningMethodES256, key, "ec1", validClaims())); err != nil {
+		t.Errorf("Verify(kid=ec1) error = %v", err)
+	}
+	// Токен без kid проверяется единственным ключом набора
+	if _, err := a.Verify(context.Background(), sign(t, jwt.SigningMethodES256, key, "", validClaims())); err != nil {
+		t.Errorf("Verify(no kid) error = %v", err)
+	}
+}
+
+func TestJWKSURL(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ec key: %v", err)
+	}
+	jwks := ecJWKS(t, "ec1", key)
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		_, _ = w.Write(jwks)
+	}))
+	defer srv.Close()
+
+	a := newAuthenticator(t, Config{JWKSURL: srv.URL})
+
+	for range 3 {
+		if _, err := a.Verify(context.Background(), sign(t, jwt.SigningMethodES256, key, "ec1", validClaims())); err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+	}
+	// Неизвестный kid сразу после загрузки не вызывает повторный запрос
+	if _, err := a.Verify(context.Background(), sign(t, jwt.SigningMethodES256, key, "ec2", validClaims())); err == nil {
+		t.Error("Verify(unknown kid) error = nil")
+	}
+	if requests != 1 {
+		t.Errorf("JWKS requests = %d, want 1 (cached)", requests)
+	}
+}
+
+func TestNewRequiresKeys(t *testing.T) {
+	if _, err := New(context.Background(), Config{}, nil); err == nil {
+		t.Error("New(empty config) error = nil")
+	}
+	if _, err := New(context.Background(), Config{JWKSURL: "http://127.0.0.1:1/jwks"}, nil); err == nil {
+		t.Error("New(unreachable JWKS) error = nil")
+	}
+}
+
+// --- HTTP middleware ---
+
+func TestHTTPMiddleware(t *testing.T) {
+	key := newRSAKey(t)
+	a := newAuthenticator(t, Config{
+		Keys:        map[string]any{"": &key.PublicKey},
+		PublicPaths: []string{"/v1/public/*"},
+	})
+
+	var gotSub string
+	h := a.HTTPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, ok := FromContext(r.Context()); ok {
+			gotSub = c.Subject
+		}
+	}))
+
+	tests := []struct {
+		name          string
+		path          string
+		auth          string
+		wantStatus    int
+		wantSub       string
+		wantChallenge string
+	}{
+		{"no token", "/v1/orders", "", http.StatusUnauthorized, "", `Bearer realm="api"`},
+		{"bad token", "/v1/orders", "Bearer abc", http.StatusUnauthorized, "",
+			`Bearer realm="api", error="invalid_token", error_description="invalid token"`},
+		{"basic scheme", "/v1/orders", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, "",
+			`Bearer realm="api", error="invalid_token", error_description="missing bearer token"`},
+		{"valid", "/v1/orders", "Bearer " + sign(t, jwt.SigningMethodRS256, key, "", validClaims()), http.StatusOK, "user-1", ""},
+		{"public", "/v1/public/info", "", http.StatusOK, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSub = ""
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if gotSub != tt.wantSub {
+				t.Errorf("claims subject = %q, want %q", gotSub, tt.wantSub)
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+					t.Errorf("Content-Type = %q", ct)
+				}
+			}
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.wantChallenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.wantChallenge)
+			}
+		})
+	}
+}
+
+// --- gRPC interceptor ---
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	key := newRSAKey(t)
+	a := newAuthenticator(t, Config{
+		Keys:          map[string]any{"": &key.PublicKey},
+		PublicMethods: []string{"/public.Service/*"},
+	})
+	interceptor := a.UnaryServerInterceptor()
+
+	handler := func(ctx context.Context, _ any) (any, error) {
+		c, ok := FromContext(ctx)
+		if !ok {
+			return "", nil
+		}
+		return c.Subject, nil
+	}
+
+	token := sign(t, jwt.SigningMethodRS256, key, "", validClaims())
+
+	tests := []struct {
+		name     string
+		method   string
+		md       metadata.MD
+		wantCode codes.Code
+		wantSub  string
+	}{
+		{"no token", "/orders.OrderService/Get", nil, codes.Unauthenticated, ""},
+		{"bad token", "/orders.OrderService/Get", metadata.Pairs("authorization", "Bearer abc"), codes.Unauthenticated, ""},
+		{"valid", "/orders.OrderService/Get", metadata.Pairs("authorization", "Bearer "+token), codes.OK, "user-1"},
+		{"public", "/public.Service/Info", nil, codes.OK, ""},
+		{"health", "/grpc.health.v1.Health/Check", nil, codes.OK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %v, want %v (err = %v)", code, tt.wantCode, err)
+			}
+			if err == nil && resp != tt.wantSub {
+				t.Errorf("claims subject = %v, want %q", resp, tt.wantSub)
+			}
+		})
+	}
+}
+
+// --- Policy ---
+
+func TestPolicyAuthorize(t *testing.T) {
+	a := newAuthenticator(t, Config{
+		Keys:          map[string]any{"": []byte("secret")},
+		PublicMethods: []string{"/orders.OrderService/ListPublic"},
+	})
+
+	var audited []Decision
+	p, err := a.NewPolicy(PolicyConfig{
+		DenyByDefault: true,
+		Rules: map[string]Rule{
+			"/orders.OrderService/*":      {},
+			"/orders.OrderService/Cancel": {Roles: []string{"admin", "support"}},
+			"/orders.OrderService/Refund": {Scopes: []string{"orders:write", "payments:write"}},
+			"/orders.OrderService/Update": {Check: func(_ context.Context, c *Claims) error {
+				if c.Raw["tenant"] != "acme" {
+					return errors.New("wrong tenant")
+				}
+				return nil
+			}},
+		},
+		Audit: func(_ context.Context, d Decision) { audited = append(audited, d) },
+	})
+	if err != nil {
+		t.Fatalf("NewPolicy: %v", err)
+	}
+
+	user := &Claims{Roles: []string{"user"}, Scope: "orders:write", Raw: map[string]any{"tenant": "acme"}}
+	admin := &Claims{Roles: []string{"admin"}, Scope: "orders:write payments:write"}
+
+	tests := []struct {
+		name     string
+		method   string
+		claims   *Claims
+		wantCode codes.Code
+	}{
+		{"prefix rule", "/orders.OrderService/Get", user, codes.OK},
+		{"role denied", "/orders.OrderService/Cancel", user, codes.PermissionDenied},
+		{"role allowed", "/orders.OrderService/Cancel", admin, codes.OK},
+		{"missing scope", "/orders.OrderService/Refund", user, codes.PermissionDenied},
+		{"all scopes", "/orders.OrderService/Refund", admin, codes.OK},
+		{"check allowed", "/orders.OrderService/Update", user, codes.OK},
+		{"check denied", "/orders.OrderService/Update", admin, codes.PermissionDenied},
+		{"no claims", "/orders.OrderService/Get", nil, codes.Unauthenticated},
+		{"deny by default", "/billing.BillingService/Pay", admin, codes.PermissionDenied},
+		{"public method", "/orders.OrderService/ListPublic", nil, codes.OK},
+		{"health", "/grpc.health.v1.Health/Check", nil, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.claims != nil {
+				ctx = NewContext(ctx, tt.claims)
+			}
+			err := p.Authorize(ctx, tt.method)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("Authorize(%s) code = %v, want %v (err = %v)", tt.method, code, tt.wantCode, err)
+			}
+		})
+	}
+
+	// Публичные методы не проходят через политику и не аудируются
+	if len(audited) != len(tests)-2 {
+		t.Errorf("audited %d decisions, want %d", len(audited), len(tests)-2)
+	}
+}
+
+// TestPolicyPublicPath проверяет, что запрос, пропущенный HTTPMiddleware по PublicPaths,
+// не проверяется политикой, а тот же метод вне публичного пути — проверяется.
+func TestPolicyPublicPath(t *testing.T) {
+	a := newAuthenticator(t, Config{
+		Keys:        map[string]any{"": []byte("secret")},
+		PublicPaths: []string{"/v1/public/*"},
+	})
+	p, err := a.NewPolicy(PolicyConfig{DenyByDefault: true})
+	if err != nil {
+		t.Fatalf("NewPolicy: %v", err)
+	}
+
+	var got error
+	h := a.HTTPMiddleware()(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		got = p.Authorize(r.Context(), "/orders.OrderService/Get")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/public/orders", nil))
+	if got != nil {
+		t.Errorf("Authorize via public path = %v, want nil", got)
+	}
+
+	if code := status.Code(p.Authorize(context.Background(), "/orders.OrderService/Get")); code != codes.PermissionDenied {
+		t.Errorf("Authorize without public path code = %v, want %v", code, codes.PermissionDenied)
+	}
+}
+
+func TestNewPolicyInvalidRule(t *testing.T) {
+	a := newAuthenticator(t, Config{Keys: map[string]any{"": []byte("secret")}})
+	for _, pattern := range []string{"orders.OrderService/Cancel", "/orders.*/Cancel"} {
+		if _, err := a.NewPolicy(PolicyConfig{Rules: map[string]Rule{pattern: {}}}); err == nil {
+			t.Errorf("NewPolicy(%q) error = nil", pattern)
+		}
+	}
+}
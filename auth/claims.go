@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims — проверенные claims JWT: стандартные поля, роли и scopes.
+// Остальные claims доступны в Raw.
+type Claims struct {
+	jwt.RegisteredClaims
+
+	// Roles — claim "roles".
+	Roles []string `json:"roles,omitempty"`
+	// Scope — claim "scope", scopes через пробел (RFC 8693).
+	Scope string `json:"scope,omitempty"`
+	// Raw — все claims токена как есть.
+	Raw map[string]any `json:"-"`
+}
+
+// UnmarshalJSON разбирает типизированные поля и сохраняет все claims в Raw.
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	type plain Claims
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &c.Raw)
+}
+
+// Scopes возвращает scopes из claim "scope" или, если его нет, из массива "scp".
+func (c *Claims) Scopes() []string {
+	if c.Scope != "" {
+		return strings.Fields(c.Scope)
+	}
+	scp, _ := c.Raw["scp"].([]any)
+	scopes := make([]string, 0, len(scp))
+	for _, s := range scp {
+		if str, ok := s.(string); ok {
+			scopes = append(scopes, str)
+		}
+	}
+	return scopes
+}
+
+// HasRole проверяет наличие роли.
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// HasScope проверяет наличие scope.
+func (c *Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes(), scope)
+}
+
+type ctxKey struct{}
+
+// NewContext возвращает копию ctx с claims.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, ctxKey{}, claims)
+}
+
+// FromContext возвращает claims аутентифицированного запроса.
+// false — запрос не аутентифицирован (публичный метод или auth не подключён).
+func FromContext(ctx context.Context) (*Claims, bool) {
+	c, ok := ctx.Value(ctxKey{}).(*Claims)
+	return c, ok
+}
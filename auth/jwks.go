@@ -0,0 +1,260 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultJWKSRefreshInterval = 5 * time.Minute
+	// jwksMinRefreshInterval — не чаще этого обновляем JWKS по неизвестному kid (ротация ключей у IdP).
+	jwksMinRefreshInterval = 30 * time.Second
+	jwksFetchTimeout       = 10 * time.Second
+	jwksMaxBodyBytes       = 1 << 20
+)
+
+// jwk — ключ из JWKS документа (RFC 7517). Поддерживаются RSA, EC (P-256/384/521) и OKP (Ed25519).
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// parseJWKS разбирает JWKS документ. Ключи неподдерживаемых типов и ключи шифрования пропускаются.
+func parseJWKS(data []byte, log *slog.Logger) (map[string]any, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+
+	keys := make(map[string]any, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			log.Warn("Ключ JWKS пропущен", slog.String("kid", k.Kid), slog.String("error", err.Error()))
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks: no usable signing keys")
+	}
+	return keys, nil
+}
+
+// jwksSource загружает JWKS из файла или по URL и кэширует ключи на interval.
+// При ошибке обновления продолжает работать с прежними ключами.
+type jwksSource struct {
+	file     string
+	url      string
+	client   *http.Client
+	interval time.Duration
+	log      *slog.Logger
+
+	loadMu     sync.Mutex // один синхронный запрос JWKS одновременно
+	refreshing atomic.Bool
+
+	mu          sync.Mutex
+	keys        map[string]any
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+// load загружает JWKS и обновляет кэш.
+func (s *jwksSource) load(ctx context.Context) error {
+	data, err := s.read(ctx)
+	if err != nil {
+		return err
+	}
+	keys, err := parseJWKS(data, s.log)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.attemptedAt = s.fetchedAt
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *jwksSource) read(ctx context.Context) ([]byte, error) {
+	if s.file != "" {
+		data, err := os.ReadFile(s.file)
+		if err != nil {
+			return nil, fmt.Errorf("read jwks: %w", err)
+		}
+		return data, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, jwksFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, jwksMaxBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	return data, nil
+}
+
+// key возвращает ключ по kid. Устаревший кэш обновляется в фоне, не задерживая запрос.
+// Если kid не найден (ротация ключей у IdP), JWKS перечитывается синхронно.
+// Попытки обновления — не чаще jwksMinRefreshInterval, в т.ч. после ошибок.
+func (s *jwksSource) key(ctx context.Context, kid string) (any, bool) {
+	if key, ok := s.lookup(kid); ok {
+		if s.stale() && s.refreshing.CompareAndSwap(false, true) {
+			go func() {
+				defer s.refreshing.Store(false)
+				s.refresh(context.Background())
+			}()
+		}
+		return key, true
+	}
+
+	s.loadMu.Lock()
+	defer s.loadMu.Unlock()
+
+	// Пока ждали loadMu, ключи мог обновить другой запрос
+	if key, ok := s.lookup(kid); ok || !s.canRetry() {
+		return key, ok
+	}
+	s.refresh(ctx)
+	return s.lookup(kid)
+}
+
+func (s *jwksSource) lookup(kid string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// stale сообщает, что кэш старше interval и можно пробовать обновить его.
+func (s *jwksSource) stale() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Since(s.fetchedAt) >= s.interval && time.Since(s.attemptedAt) >= jwksMinRefreshInterval
+}
+
+func (s *jwksSource) canRetry() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Since(s.attemptedAt) >= jwksMinRefreshInterval
+}
+
+// refresh перечитывает JWKS, при ошибке оставляет прежние ключи.
+func (s *jwksSource) refresh(ctx context.Context) {
+	s.mu.Lock()
+	s.attemptedAt = time.Now()
+	s.mu.Unlock()
+
+	if err := s.load(ctx); err != nil {
+		s.log.Error("Не удалось обновить JWKS, используются прежние ключи", slog.String("error", err.Error()))
+	}
+}
+
+// single возвращает единственный ключ набора — для токенов без kid.
+func (s *jwksSource) single() (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.keys) != 1 {
+		return nil, false
+	}
+	for _, k := range s.keys {
+		return k, true
+	}
+	return nil, false
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	platformerrors "github.com/vovanwin/platform/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// IsPublicMethod сообщает, доступен ли gRPC метод без токена.
+func (a *Authenticator) IsPublicMethod(fullMethod string) bool {
+	return matchAny(fullMethod, defaultPublicMethods) || matchAny(fullMethod, a.cfg.PublicMethods)
+}
+
+// IsPublicPath сообщает, доступен ли HTTP путь без токена.
+func (a *Authenticator) IsPublicPath(path string) bool {
+	return matchAny(path, a.cfg.PublicPaths)
+}
+
+type publicPathKey struct{}
+
+// NewPublicPathContext помечает ctx запроса, пропущенного HTTPMiddleware без токена по PublicPaths.
+// Policy не проверяет такие запросы.
+func NewPublicPathContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, publicPathKey{}, true)
+}
+
+// IsPublicPathContext сообщает, пришёл ли запрос по HTTP пути из PublicPaths.
+func IsPublicPathContext(ctx context.Context) bool {
+	public, _ := ctx.Value(publicPathKey{}).(bool)
+	return public
+}
+
+// authenticate проверяет значение Authorization и возвращает context с claims.
+func (a *Authenticator) authenticate(ctx context.Context, authorization, target string) (context.Context, error) {
+	token, ok := bearerToken(authorization)
+	if !ok {
+		return ctx, platformerrors.Unauthenticated("missing bearer token")
+	}
+
+	claims, err := a.Verify(ctx, token)
+	if err != nil {
+		a.log.DebugContext(ctx, "Токен отклонён",
+			slog.String("target", target),
+			slog.String("error", err.Error()),
+		)
+		return ctx, platformerrors.Unauthenticated("invalid token").WithCause(err)
+	}
+	return NewContext(ctx, claims), nil
+}
+
+// OptionalContext проверяет Bearer токен из метаданных authorization, если он есть, и возвращает
+// context с Claims. Без токена или с невалидным токеном возвращает ctx без изменений — для вызовов,
+// аутентификация которых уже выполнена раньше (напр. HTTPMiddleware перед вызовом из grpc-gateway).
+func (a *Authenticator) OptionalContext(ctx context.Context) context.Context {
+	authorization := authorizationFromMetadata(ctx)
+	if authorization == "" {
+		return ctx
+	}
+	if authed, err := a.authenticate(ctx, authorization, "optional"); err == nil {
+		return authed
+	}
+	return ctx
+}
+
+// HTTPMiddleware проверяет Bearer токен из заголовка Authorization и кладёт Claims в context.
+// Без токена или с невалидным токеном отвечает 401 с application/problem+json.
+// Запросы по PublicPaths пропускаются без проверки и помечаются NewPublicPathContext.
+func (a *Authenticator) HTTPMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if a.IsPublicPath(r.URL.Path) {
+				next.ServeHTTP(w, r.WithContext(NewPublicPathContext(r.Context())))
+				return
+			}
+
+			ctx, err := a.authenticate(r.Context(), r.Header.Get("Authorization"), r.URL.Path)
+			if err != nil {
+				p := platformerrors.NewProblem(r.Context(), w, r, err)
+				w.Header().Set("WWW-Authenticate", platformerrors.BearerChallenge(r, p.Detail))
+				platformerrors.WriteProblem(w, p)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// UnaryServerInterceptor проверяет Bearer токен из метаданных authorization
+// и кладёт Claims в context. Ошибка — UNAUTHENTICATED.
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if a.IsPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx, err := a.authenticate(ctx, authorizationFromMetadata(ctx), info.FullMethod)
+		if err != nil {
+			return nil, platformerrors.ToStatus(err).Err()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor — аналог UnaryServerInterceptor для стримов.
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if a.IsPublicMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx, err := a.authenticate(ss.Context(), authorizationFromMetadata(ss.Context()), info.FullMethod)
+		if err != nil {
+			return platformerrors.ToStatus(err).Err()
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authorizationFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if vals := md.Get("authorization"); len(vals) > 0 {
+		return vals[0]
+	}
+	return ""
+}
+
+// serverStream подменяет context стрима на context с claims.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+
+	platformerrors "github.com/vovanwin/platform/errors"
+	"google.golang.org/grpc"
+)
+
+// Rule — требования к вызывающему метода. Пустое правило — достаточно аутентификации.
+type Rule struct {
+	// Roles — нужна хотя бы одна из ролей.
+	Roles []string `json:"roles,omitempty" yaml:"roles,omitempty"`
+	// Scopes — нужны все scopes.
+	Scopes []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	// Check — дополнительная проверка в коде (напр. владелец ресурса из claims.Raw).
+	// Ошибка означает отказ; её текст попадает в аудит, но не клиенту.
+	Check func(ctx context.Context, claims *Claims) error `json:"-" yaml:"-"`
+}
+
+// PolicyConfig — правила авторизации по полному gRPC методу.
+type PolicyConfig struct {
+	// Rules — правила по методу: полное имя ("/orders.OrderService/Cancel")
+	// или префикс со звёздочкой ("/orders.OrderService/*"). Точное имя важнее префикса,
+	// из префиксов выбирается самый длинный.
+	Rules map[string]Rule `json:"rules" yaml:"rules"`
+	// DenyByDefault — запрещать методы без правила. Публичные методы Authenticator и health разрешены всегда.
+	// Запросы по HTTP путям из Config.PublicPaths политика тоже не проверяет: путь открыт без токена,
+	// поэтому вызванный через него метод считается публичным независимо от DenyByDefault и правил.
+	// Тот же метод по gRPC или по непубличному пути проверяется как обычно.
+	DenyByDefault bool `json:"deny_by_default" yaml:"deny_by_default"`
+	// Audit — получает каждое решение (напр. для записи в журнал аудита). Решения также логируются:
+	// отказы — Warn, разрешения — Debug.
+	Audit func(ctx context.Context, d Decision) `json:"-" yaml:"-"`
+}
+
+// Decision — решение политики по одному вызову.
+type Decision struct {
+	Method  string
+	Subject string
+	Allowed bool
+	// Rule — сработавшее правило (ключ PolicyConfig.Rules) или пустая строка.
+	Rule string
+	// Reason — причина решения.
+	Reason string
+}
+
+// Policy проверяет, может ли аутентифицированный вызывающий вызвать метод.
+type Policy struct {
+	auth  *Authenticator
+	cfg   PolicyConfig
+	exact map[string]Rule
+	// prefixes отсортированы по убыванию длины — первый совпавший самый специфичный
+	prefixes []string
+}
+
+// NewPolicy создаёт политику поверх Authenticator: claims берутся из context,
+// а публичные методы Authenticator не проверяются.
+func (a *Authenticator) NewPolicy(cfg PolicyConfig) (*Policy, error) {
+	p := &Policy{auth: a, cfg: cfg, exact: make(map[string]Rule)}
+
+	var errs []error
+	for pattern, rule := range cfg.Rules {
+		if !strings.HasPrefix(pattern, "/") {
+			errs = append(errs, fmt.Errorf("rule %q: method must start with /", pattern))
+			continue
+		}
+		prefix, isPrefix := strings.CutSuffix(pattern, "*")
+		if strings.Contains(prefix, "*") {
+			errs = append(errs, fmt.Errorf("rule %q: * is allowed only at the end", pattern))
+			continue
+		}
+		if isPrefix {
+			p.prefixes = append(p.prefixes, pattern)
+			continue
+		}
+		p.exact[pattern] = rule
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("auth policy: %w", err)
+	}
+
+	sort.Slice(p.prefixes, func(i, j int) bool { return len(p.prefixes[i]) > len(p.prefixes[j]) })
+	return p, nil
+}
+
+// Matches сообщает, покрывает ли правило с ключом pattern метод fullMethod.
+func Matches(pattern, fullMethod string) bool {
+	return matchAny(fullMethod, []string{pattern})
+}
+
+// Patterns возвращает ключи всех правил политики.
+func (p *Policy) Patterns() []string {
+	patterns := make([]string, 0, len(p.cfg.Rules))
+	for pattern := range p.cfg.Rules {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+func (p *Policy) match(fullMethod string) (string, Rule, bool) {
+	if rule, ok := p.exact[fullMethod]; ok {
+		return fullMethod, rule, true
+	}
+	for _, pattern := range p.prefixes {
+		if Matches(pattern, fullMethod) {
+			return pattern, p.cfg.Rules[pattern], true
+		}
+	}
+	return "", Rule{}, false
+}
+
+// Authorize проверяет вызов метода по claims из context.
+// Возвращает *errors.Error с кодом UNAUTHENTICATED (нет claims) или PERMISSION_DENIED.
+// Публичные методы и запросы по PublicPaths (IsPublicPathContext) не проверяются.
+func (p *Policy) Authorize(ctx context.Context, fullMethod string) error {
+	if p.auth.IsPublicMethod(fullMethod) || IsPublicPathContext(ctx) {
+		return nil
+	}
+
+	claims, authenticated := FromContext(ctx)
+	d := Decision{Method: fullMethod}
+	if authenticated {
+		d.Subject = claims.Subject
+	}
+
+	pattern, rule, found := p.match(fullMethod)
+	d.Rule = pattern
+
+	var err error
+	switch {
+	case !found && p.cfg.DenyByDefault:
+		d.Reason = "no rule for method"
+		err = platformerrors.PermissionDenied("access denied")
+	case !found:
+		d.Reason = "no rule, allowed by default"
+	case !authenticated:
+		d.Reason = "no claims in context"
+		err = platformerrors.Unauthenticated("authentication required")
+	default:
+		d.Reason, err = evaluate(ctx, rule, claims)
+	}
+
+	d.Allowed = err == nil
+	p.audit(ctx, d)
+	return err
+}
+
+// evaluate проверяет роли, scopes и Check правила.
+func evaluate(ctx context.Context, rule Rule, claims *Claims) (string, error) {
+	if len(rule.Roles) > 0 && !slices.ContainsFunc(rule.Roles, claims.HasRole) {
+		return "missing role: one of " + strings.Join(rule.Roles, ", "), platformerrors.PermissionDenied("access denied")
+	}
+	for _, scope := range rule.Scopes {
+		if !claims.HasScope(scope) {
+			return "missing scope: " + scope, platformerrors.PermissionDenied("access denied")
+		}
+	}
+	if rule.Check != nil {
+		if err := rule.Check(ctx, claims); err != nil {
+			return "check failed: " + err.Error(), platformerrors.PermissionDenied("access denied")
+		}
+	}
+	return "rule matched", nil
+}
+
+func (p *Policy) audit(ctx context.Context, d Decision) {
+	attrs := []any{
+		slog.String("method", d.Method),
+		slog.String("subject", d.Subject),
+		slog.String("rule", d.Rule),
+		slog.String("reason", d.Reason),
+	}
+	if d.Allowed {
+		p.auth.log.DebugContext(ctx, "Доступ разрешён", attrs...)
+	} else {
+		p.auth.log.WarnContext(ctx, "Доступ запрещён", attrs...)
+	}
+
+	if p.cfg.Audit != nil {
+		p.cfg.Audit(ctx, d)
+	}
+}
+
+// UnaryServerInterceptor применяет политику к gRPC вызовам. Должен стоять после
+// Authenticator.UnaryServerInterceptor, который кладёт claims в context.
+func (p *Policy) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := p.Authorize(ctx, info.FullMethod); err != nil {
+			return nil, platformerrors.ToStatus(err).Err()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor — аналог UnaryServerInterceptor для стримов.
+func (p *Policy) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := p.Authorize(ss.Context(), info.FullMethod); err != nil {
+			return platformerrors.ToStatus(err).Err()
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,190 @@
+// Package errors — единая модель ошибок для gRPC и REST.
+//
+// Обработчики возвращают типизированные доменные ошибки (NotFound, Conflict, Validation и т.д.),
+// interceptors переводят их в status.Status с errdetails, а gateway отдаёт клиенту
+// RFC 7807 application/problem+json с тем же кодом, причиной и нарушениями полей.
+package errors
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FieldViolation — нарушение валидации одного поля (errdetails.BadRequest_FieldViolation).
+type FieldViolation struct {
+	// Field путь к полю, напр. "user.email".
+	Field string `json:"field"`
+	// Description описание нарушения для клиента.
+	Description string `json:"description"`
+}
+
+// Error — доменная ошибка с gRPC кодом и деталями.
+// Создаётся конструкторами NotFound, Conflict, Validation и т.д., дополняется методами With*.
+type Error struct {
+	// Code gRPC код, определяет и HTTP статус ответа gateway.
+	Code codes.Code
+	// Message сообщение для клиента.
+	Message string
+	// Reason машиночитаемая причина в UPPER_SNAKE_CASE (errdetails.ErrorInfo.Reason).
+	Reason string
+	// Domain домен причины (errdetails.ErrorInfo.Domain), обычно имя сервиса.
+	Domain string
+	// Metadata дополнительные атрибуты причины (errdetails.ErrorInfo.Metadata).
+	Metadata map[string]string
+	// Violations нарушения валидации полей (errdetails.BadRequest).
+	Violations []FieldViolation
+
+	cause error
+}
+
+// New создаёт ошибку с произвольным gRPC кодом. Reason по умолчанию — имя кода (NOT_FOUND и т.п.).
+func New(code codes.Code, format string, args ...any) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+		Reason:  defaultReason(code),
+	}
+}
+
+// NotFound — ресурс не найден (404).
+func NotFound(format string, args ...any) *Error {
+	return New(codes.NotFound, format, args...)
+}
+
+// AlreadyExists — ресурс уже существует (409).
+func AlreadyExists(format string, args ...any) *Error {
+	return New(codes.AlreadyExists, format, args...)
+}
+
+// Conflict — конфликт состояния, напр. конкурентное изменение (409).
+func Conflict(format string, args ...any) *Error {
+	return New(codes.Aborted, format, args...).WithReason("CONFLICT")
+}
+
+// Validation — некорректные входные данные (400) с нарушениями по полям.
+func Validation(message string, violations ...FieldViolation) *Error {
+	e := New(codes.InvalidArgument, "%s", message).WithReason("VALIDATION_FAILED")
+	e.Violations = violations
+	return e
+}
+
+// FailedPrecondition — операция невозможна в текущем состоянии ресурса (400).
+func FailedPrecondition(format string, args ...any) *Error {
+	return New(codes.FailedPrecondition, format, args...)
+}
+
+// Unauthenticated — запрос без валидных учётных данных (401).
+func Unauthenticated(format string, args ...any) *Error {
+	return New(codes.Unauthenticated, format, args...)
+}
+
+// PermissionDenied — недостаточно прав (403).
+func PermissionDenied(format string, args ...any) *Error {
+	return New(codes.PermissionDenied, format, args...)
+}
+
+// ResourceExhausted — превышены лимиты или квоты (429).
+func ResourceExhausted(format string, args ...any) *Error {
+	return New(codes.ResourceExhausted, format, args...)
+}
+
+// Unavailable — зависимость временно недоступна, запрос можно повторить (503).
+func Unavailable(format string, args ...any) *Error {
+	return New(codes.Unavailable, format, args...)
+}
+
+// Internal — внутренняя ошибка (500). Причину передавайте через WithCause:
+// она логируется, но не уходит клиенту.
+func Internal(format string, args ...any) *Error {
+	return New(codes.Internal, format, args...)
+}
+
+// WithReason задаёт машиночитаемую причину.
+func (e *Error) WithReason(reason string) *Error {
+	e.Reason = reason
+	return e
+}
+
+// WithDomain задаёт домен причины.
+func (e *Error) WithDomain(domain string) *Error {
+	e.Domain = domain
+	return e
+}
+
+// WithMetadata добавляет атрибут причины.
+func (e *Error) WithMetadata(key, value string) *Error {
+	if e.Metadata == nil {
+		e.Metadata = make(map[string]string)
+	}
+	e.Metadata[key] = value
+	return e
+}
+
+// WithField добавляет нарушение валидации поля.
+func (e *Error) WithField(field, description string) *Error {
+	e.Violations = append(e.Violations, FieldViolation{Field: field, Description: description})
+	return e
+}
+
+// WithCause сохраняет исходную ошибку для errors.Is/As и логов. Клиенту она не передаётся.
+func (e *Error) WithCause(err error) *Error {
+	e.cause = err
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Unwrap возвращает исходную ошибку, заданную через WithCause.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// GRPCStatus позволяет status.FromError и status.Code работать с *Error напрямую.
+func (e *Error) GRPCStatus() *status.Status {
+	return e.status()
+}
+
+// Is сравнивает ошибки по коду и причине: errors.Is(err, errors.NotFound("")) истинно
+// для любой NotFound ошибки.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return e.Code == t.Code && e.Reason == t.Reason
+}
+
+// defaultReason возвращает имя кода в UPPER_SNAKE_CASE: codes.NotFound → "NOT_FOUND".
+func defaultReason(code codes.Code) string {
+	if name, ok := codeNames[code]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+var codeNames = map[codes.Code]string{
+	codes.OK:                 "OK",
+	codes.Canceled:           "CANCELLED",
+	codes.Unknown:            "UNKNOWN",
+	codes.InvalidArgument:    "INVALID_ARGUMENT",
+	codes.DeadlineExceeded:   "DEADLINE_EXCEEDED",
+	codes.NotFound:           "NOT_FOUND",
+	codes.AlreadyExists:      "ALREADY_EXISTS",
+	codes.PermissionDenied:   "PERMISSION_DENIED",
+	codes.ResourceExhausted:  "RESOURCE_EXHAUSTED",
+	codes.FailedPrecondition: "FAILED_PRECONDITION",
+	codes.Aborted:            "ABORTED",
+	codes.OutOfRange:         "OUT_OF_RANGE",
+	codes.Unimplemented:      "UNIMPLEMENTED",
+	codes.Internal:           "INTERNAL",
+	codes.Unavailable:        "UNAVAILABLE",
+	codes.DataLoss:           "DATA_LOSS",
+	codes.Unauthenticated:    "UNAUTHENTICATED",
+}
@@ -0,0 +1,124 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProblemContentType — media type ответа об ошибке по RFC 7807.
+const ProblemContentType = "application/problem+json"
+
+// Problem — тело ответа об ошибке по RFC 7807 с расширениями платформы.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Code — имя gRPC кода (NOT_FOUND и т.п.), одинаковое для обоих транспортов.
+	Code string `json:"code"`
+	// Reason, Domain и Metadata — из errdetails.ErrorInfo.
+	Reason   string            `json:"reason,omitempty"`
+	Domain   string            `json:"domain,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Violations — из errdetails.BadRequest.
+	Violations []FieldViolation `json:"violations,omitempty"`
+	// TraceID — идентификатор трейса для поиска в Tempo/Grafana.
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// NewProblem собирает Problem из ошибки и HTTP запроса.
+// trace_id берётся из заголовка X-Trace-ID ответа (TraceIDMiddleware), иначе из спана в контексте.
+func NewProblem(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) Problem {
+	var custom *runtime.HTTPStatusError
+	if stderrors.As(err, &custom) {
+		err = custom.Err
+	}
+
+	// Ошибки без кода FromError превращает в INTERNAL с обезличенным сообщением
+	e := FromError(err)
+
+	httpStatus := runtime.HTTPStatusFromCode(e.Code)
+	if custom != nil {
+		httpStatus = custom.HTTPStatus
+	}
+
+	p := Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(httpStatus),
+		Status:     httpStatus,
+		Detail:     e.Message,
+		Instance:   r.URL.Path,
+		Code:       defaultReason(e.Code),
+		Reason:     e.Reason,
+		Domain:     e.Domain,
+		Metadata:   e.Metadata,
+		Violations: e.Violations,
+		TraceID:    w.Header().Get("X-Trace-ID"),
+	}
+	if p.TraceID == "" {
+		if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+			p.TraceID = sc.TraceID().String()
+		}
+	}
+	return p
+}
+
+// GatewayErrorHandler — обработчик ошибок grpc-gateway (runtime.WithErrorHandler),
+// отдающий application/problem+json. Ошибки маршрутизации (404, 405) gateway передаёт сюда же.
+// Заголовки из gRPC header metadata передаются с префиксом Grpc-Metadata-, как в обработчике по умолчанию.
+func GatewayErrorHandler(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	p := NewProblem(ctx, w, r, err)
+
+	w.Header().Del("Trailer")
+	w.Header().Del("Transfer-Encoding")
+	if md, ok := runtime.ServerMetadataFromContext(ctx); ok {
+		for k, vs := range md.HeaderMD {
+			for _, v := range vs {
+				w.Header().Add(runtime.MetadataHeaderPrefix+k, v)
+			}
+		}
+	}
+	if p.Code == "UNAUTHENTICATED" {
+		w.Header().Set("WWW-Authenticate", BearerChallenge(r, p.Detail))
+	}
+
+	WriteProblem(w, p)
+}
+
+// BearerChallenge возвращает значение WWW-Authenticate для ответа 401 по RFC 6750:
+// без токена в запросе — только realm, с токеном — error="invalid_token" и описание ошибки.
+func BearerChallenge(r *http.Request, description string) string {
+	if r.Header.Get("Authorization") == "" {
+		return `Bearer realm="api"`
+	}
+	challenge := `Bearer realm="api", error="invalid_token"`
+	if description != "" {
+		challenge += `, error_description="` + strings.Map(challengeChar, description) + `"`
+	}
+	return challenge
+}
+
+// challengeChar оставляет в error_description только допустимые RFC 6750 символы
+// (%x20-21 / %x23-5B / %x5D-7E): без кавычек, обратной косой черты и не-ASCII.
+func challengeChar(r rune) rune {
+	if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+		return -1
+	}
+	return r
+}
+
+// WriteProblem записывает Problem в ответ. Используется и вне gateway — в HTTP middleware,
+// чтобы ошибки транспорта (лимиты, аутентификация) выглядели так же, как ошибки обработчиков.
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
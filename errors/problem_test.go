@@ -0,0 +1,40 @@
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGatewayErrorHandlerChallenge(t *testing.T) {
+	tests := []struct {
+		name string
+		auth string
+		err  error
+		want string
+	}{
+		{"no token", "", status.Error(codes.Unauthenticated, "missing bearer token"), `Bearer realm="api"`},
+		{"bad token", "Bearer abc", status.Error(codes.Unauthenticated, "invalid token"),
+			`Bearer realm="api", error="invalid_token", error_description="invalid token"`},
+		{"unsafe description", "Bearer abc", status.Error(codes.Unauthenticated, "token \"x\"\nexpired\\ ✗"),
+			`Bearer realm="api", error="invalid_token", error_description="token xexpired "`},
+		{"not unauthenticated", "Bearer abc", status.Error(codes.PermissionDenied, "denied"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/orders", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			GatewayErrorHandler(req.Context(), nil, nil, rec, req, tt.err)
+
+			if got := rec.Header().Get("WWW-Authenticate"); got != tt.want {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
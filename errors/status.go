@@ -0,0 +1,143 @@
+package errors
+
+import (
+	"context"
+	stderrors "errors"
+	"log/slog"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// internalMessage — сообщение клиенту для ошибок без кода: детали не раскрываются.
+const internalMessage = "internal error"
+
+// status собирает status.Status с errdetails.ErrorInfo и errdetails.BadRequest.
+func (e *Error) status() *status.Status {
+	st := status.New(e.Code, e.Message)
+
+	var details []protoadapt.MessageV1
+	if e.Reason != "" {
+		details = append(details, &errdetails.ErrorInfo{
+			Reason:   e.Reason,
+			Domain:   e.Domain,
+			Metadata: e.Metadata,
+		})
+	}
+	if len(e.Violations) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, v := range e.Violations {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Description,
+			})
+		}
+		details = append(details, br)
+	}
+	if len(details) == 0 {
+		return st
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+	return withDetails
+}
+
+// ToStatus переводит ошибку в gRPC статус:
+//   - *Error (в т.ч. обёрнутая) — код, сообщение и errdetails;
+//   - ошибка, уже несущая gRPC статус, — как есть;
+//   - context.Canceled и context.DeadlineExceeded — CANCELLED и DEADLINE_EXCEEDED;
+//   - любая другая — INTERNAL без деталей исходной ошибки.
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e.status()
+	}
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+
+	switch {
+	case stderrors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, err.Error())
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, err.Error())
+	default:
+		return status.New(codes.Internal, internalMessage)
+	}
+}
+
+// FromError восстанавливает *Error из ошибки gRPC клиента или сервера, разбирая errdetails.
+// Для ошибок без статуса возвращает INTERNAL с исходной ошибкой в качестве причины.
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e
+	}
+
+	st := ToStatus(err)
+	e = &Error{Code: st.Code(), Message: st.Message()}
+	if _, ok := status.FromError(err); !ok {
+		e.cause = err
+	}
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			e.Reason = d.GetReason()
+			e.Domain = d.GetDomain()
+			e.Metadata = d.GetMetadata()
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				e.Violations = append(e.Violations, FieldViolation{Field: v.GetField(), Description: v.GetDescription()})
+			}
+		}
+	}
+	return e
+}
+
+// UnaryServerInterceptor переводит ошибки обработчиков в gRPC статусы через ToStatus.
+// Ошибки без кода логируются и возвращаются клиенту как INTERNAL.
+// Должен стоять последним в цепочке, чтобы метрики и логи видели итоговый код.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, convert(ctx, info.FullMethod, err)
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor — аналог UnaryServerInterceptor для стримов.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return convert(ss.Context(), info.FullMethod, err)
+		}
+		return nil
+	}
+}
+
+func convert(ctx context.Context, method string, err error) error {
+	st := ToStatus(err)
+	if st.Code() == codes.Internal {
+		slog.ErrorContext(ctx, "gRPC обработчик вернул внутреннюю ошибку",
+			slog.String("method", method),
+			slog.String("error", err.Error()),
+		)
+	}
+	return st.Err()
+}
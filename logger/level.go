@@ -0,0 +1,296 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	platformotel "github.com/vovanwin/platform/otel"
+)
+
+// LoggerKey — атрибут с именем логгера, который добавляет Named.
+const LoggerKey = "logger"
+
+// LevelController меняет уровень логирования во время работы: глобальный уровень и
+// переопределения для именованных логгеров (см. Named), опционально с автоматическим
+// откатом через TTL. Создаётся NewLogger, достаётся из логгера через ControllerOf.
+//
+// Переопределение для имени действует и на вложенные логгеры: "orders" включает
+// "orders.repo", если для "orders.repo" нет своего.
+type LevelController struct {
+	global slog.LevelVar
+	// named — копия переопределений для чтения без блокировки на каждой записи лога.
+	named atomic.Pointer[map[string]slog.Level]
+
+	mu        sync.Mutex
+	globalTTL *levelRevert
+	overrides map[string]*levelOverride
+}
+
+// levelOverride — переопределение уровня для имени.
+type levelOverride struct {
+	level  slog.Level
+	revert *levelRevert
+}
+
+// levelRevert — отложенный откат изменения уровня по TTL.
+type levelRevert struct {
+	timer     *time.Timer
+	expiresAt time.Time
+	// prev — значение до первого изменения с TTL; nil для переопределения, которого не было.
+	prev *slog.Level
+}
+
+// NewLevelController создаёт контроллер с глобальным уровнем level.
+func NewLevelController(level slog.Level) *LevelController {
+	c := &LevelController{overrides: make(map[string]*levelOverride)}
+	c.global.Set(level)
+	c.named.Store(&map[string]slog.Level{})
+	return c
+}
+
+// Level возвращает текущий глобальный уровень.
+func (c *LevelController) Level() slog.Level {
+	return c.global.Level()
+}
+
+// LevelFor возвращает уровень, действующий для логгера с именем name:
+// переопределение для имени или ближайшего родителя, иначе глобальный.
+func (c *LevelController) LevelFor(name string) slog.Level {
+	if name != "" {
+		named := *c.named.Load()
+		if len(named) > 0 {
+			for {
+				if level, ok := named[name]; ok {
+					return level
+				}
+				i := strings.LastIndexByte(name, '.')
+				if i < 0 {
+					break
+				}
+				name = name[:i]
+			}
+		}
+	}
+	return c.global.Level()
+}
+
+// SetLevel меняет глобальный уровень. При ttl > 0 уровень вернётся к прежнему через ttl;
+// повторный вызов с TTL продлевает откат к исходному значению, вызов без TTL отменяет его.
+func (c *LevelController) SetLevel(level slog.Level, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev := c.global.Level()
+	c.globalTTL = c.schedule(c.globalTTL, &prev, ttl, func(r *levelRevert) {
+		if c.globalTTL == r {
+			c.globalTTL = nil
+			c.global.Set(*r.prev)
+		}
+	})
+	c.global.Set(level)
+	c.publish()
+}
+
+// SetNamed переопределяет уровень для логгера name и его вложенных логгеров.
+// При ttl > 0 через ttl восстанавливается прежнее состояние (прежний уровень или его отсутствие).
+func (c *LevelController) SetNamed(name string, level slog.Level, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, ok := c.overrides[name]
+	var prev *slog.Level
+	if ok {
+		prev = &o.level
+	} else {
+		o = &levelOverride{}
+		c.overrides[name] = o
+	}
+	o.revert = c.schedule(o.revert, prev, ttl, func(r *levelRevert) {
+		if o.revert != r || c.overrides[name] != o {
+			return
+		}
+		if r.prev == nil {
+			delete(c.overrides, name)
+		} else {
+			o.level, o.revert = *r.prev, nil
+		}
+	})
+	o.level = level
+	c.publish()
+}
+
+// ResetNamed удаляет переопределение для name: логгер снова следует глобальному уровню.
+func (c *LevelController) ResetNamed(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if o, ok := c.overrides[name]; ok {
+		if o.revert != nil {
+			o.revert.timer.Stop()
+		}
+		delete(c.overrides, name)
+		c.publish()
+	}
+}
+
+// schedule планирует откат: при ttl <= 0 отменяет текущий, иначе переносит его срок,
+// сохраняя значение до первого изменения. Вызывается под c.mu, apply — тоже под c.mu.
+func (c *LevelController) schedule(cur *levelRevert, prev *slog.Level, ttl time.Duration, apply func(*levelRevert)) *levelRevert {
+	if cur != nil {
+		cur.timer.Stop()
+		prev = cur.prev
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	r := &levelRevert{expiresAt: time.Now().Add(ttl)}
+	if prev != nil {
+		p := *prev
+		r.prev = &p
+	}
+	r.timer = time.AfterFunc(ttl, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		apply(r)
+		c.publish()
+	})
+	return r
+}
+
+// publish обновляет копию переопределений для levelHandler. Вызывается под c.mu.
+func (c *LevelController) publish() {
+	named := make(map[string]slog.Level, len(c.overrides))
+	for name, o := range c.overrides {
+		named[name] = o.level
+	}
+	c.named.Store(&named)
+}
+
+// Levels возвращает глобальный уровень и переопределения для метрик.
+// Глобальный уровень — под пустым именем.
+func (c *LevelController) Levels() map[string]slog.Level {
+	levels := maps.Clone(*c.named.Load())
+	levels[""] = c.global.Level()
+	return levels
+}
+
+// LevelState — текущие уровни для /debug/loglevel.
+type LevelState struct {
+	Level     string                `json:"level"`
+	ExpiresAt *time.Time            `json:"expires_at,omitempty"`
+	Loggers   map[string]LevelEntry `json:"loggers"`
+}
+
+// LevelEntry — переопределение уровня для имени.
+type LevelEntry struct {
+	Level     string     `json:"level"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// State возвращает текущий глобальный уровень и переопределения со сроками отката.
+func (c *LevelController) State() LevelState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := LevelState{
+		Level:     c.global.Level().String(),
+		ExpiresAt: c.globalTTL.expires(),
+		Loggers:   make(map[string]LevelEntry, len(c.overrides)),
+	}
+	for name, o := range c.overrides {
+		state.Loggers[name] = LevelEntry{Level: o.level.String(), ExpiresAt: o.revert.expires()}
+	}
+	return state
+}
+
+func (r *levelRevert) expires() *time.Time {
+	if r == nil {
+		return nil
+	}
+	t := r.expiresAt
+	return &t
+}
+
+// ParseLevel разбирает уровень: DEBUG, INFO, WARN, ERROR без учёта регистра,
+// в том числе со смещением ("DEBUG-4", "INFO+2").
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q", s)
+	}
+	return level, nil
+}
+
+// levelHandler — внешний handler логгера из NewLogger: отсекает записи ниже уровня,
+// действующего для имени логгера, и добавляет имя в запись. Для запросов с отладочным
+// логированием (см. otel.DebugLog) пропускает DEBUG при любом уровне.
+type levelHandler struct {
+	inner slog.Handler
+	ctrl  *LevelController
+	opts  *Options
+	name  string
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if level < h.ctrl.LevelFor(h.name) && (level < slog.LevelDebug || !platformotel.DebugLogEnabled(ctx)) {
+		return false
+	}
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	if h.name != "" {
+		record.AddAttrs(slog.String(LoggerKey, h.name))
+	}
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{inner: h.inner.WithAttrs(attrs), ctrl: h.ctrl, opts: h.opts, name: h.name}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{inner: h.inner.WithGroup(name), ctrl: h.ctrl, opts: h.opts, name: h.name}
+}
+
+// Named возвращает логгер с именем name (атрибут "logger"), уровень которого можно
+// переопределить через LevelController.SetNamed или /debug/loglevel. Имена вложенных
+// логгеров соединяются точкой: Named(Named(l, "orders"), "repo") — "orders.repo".
+// Для логгера не из NewLogger только добавляет атрибут.
+func Named(l *slog.Logger, name string) *slog.Logger {
+	h, ok := l.Handler().(*levelHandler)
+	if !ok {
+		return l.With(slog.String(LoggerKey, name))
+	}
+	if h.name != "" {
+		name = h.name + "." + name
+	}
+	return slog.New(&levelHandler{inner: h.inner, ctrl: h.ctrl, opts: h.opts, name: name})
+}
+
+// ControllerOf возвращает LevelController логгера, созданного NewLogger (в том числе после
+// With и Named). false — логгер создан иначе или его handler обёрнут.
+func ControllerOf(l *slog.Logger) (*LevelController, bool) {
+	h, ok := l.Handler().(*levelHandler)
+	if !ok {
+		return nil, false
+	}
+	return h.ctrl, true
+}
+
+// OptionsOf возвращает Options, с которыми NewLogger создал логгер (для /debug/config).
+// Текущие уровни — в LevelController. false — логгер создан иначе или его handler обёрнут.
+func OptionsOf(l *slog.Logger) (Options, bool) {
+	h, ok := l.Handler().(*levelHandler)
+	if !ok {
+		return Options{}, false
+	}
+	return *h.opts, true
+}
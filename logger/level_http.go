@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// maxLevelRequestBytes — ограничение тела PUT /debug/loglevel.
+const maxLevelRequestBytes = 4 << 10
+
+// levelRequest — тело PUT /debug/loglevel.
+type levelRequest struct {
+	// Logger имя логгера (см. Named); пусто — глобальный уровень.
+	Logger string `json:"logger"`
+	// Level новый уровень; пусто для Logger — удалить переопределение.
+	Level string `json:"level"`
+	// TTL через сколько откатить изменение ("15m"); пусто — без отката.
+	TTL string `json:"ttl"`
+}
+
+// Handler возвращает HTTP handler для /debug/loglevel:
+//   - GET — текущий глобальный уровень и переопределения со сроками отката (JSON)
+//   - PUT {"level":"DEBUG","ttl":"15m"} — глобальный уровень
+//   - PUT {"logger":"orders","level":"DEBUG","ttl":"15m"} — переопределение для логгера
+//   - PUT {"logger":"orders","level":""} — удалить переопределение
+//
+// Ответ на PUT — состояние после изменения. Монтируется сервером на debug порт.
+func (c *LevelController) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut:
+			if err := c.apply(w, r); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.State())
+	})
+}
+
+// apply разбирает тело PUT и меняет уровень.
+func (c *LevelController) apply(w http.ResponseWriter, r *http.Request) error {
+	var req levelRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxLevelRequestBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid ttl %q", req.TTL)
+		}
+		ttl = d
+	}
+
+	if req.Logger != "" && req.Level == "" {
+		c.ResetNamed(req.Logger)
+		return nil
+	}
+
+	level, err := ParseLevel(req.Level)
+	if err != nil {
+		return err
+	}
+	if req.Logger == "" {
+		c.SetLevel(level, ttl)
+	} else {
+		c.SetNamed(req.Logger, level, ttl)
+	}
+	return nil
+}
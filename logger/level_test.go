@@ -0,0 +1,236 @@
+package logger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelControllerTTL(t *testing.T) {
+	const ttl = 20 * time.Millisecond
+
+	tests := []struct {
+		name   string
+		set    func(c *LevelController)
+		logger string
+		// wantSet — уровень сразу после set, wantAfter — после истечения ttl
+		wantSet   slog.Level
+		wantAfter slog.Level
+	}{
+		{
+			name:      "global revert",
+			set:       func(c *LevelController) { c.SetLevel(slog.LevelDebug, ttl) },
+			wantSet:   slog.LevelDebug,
+			wantAfter: slog.LevelInfo,
+		},
+		{
+			name: "global extend keeps original value",
+			set: func(c *LevelController) {
+				c.SetLevel(slog.LevelDebug, ttl)
+				c.SetLevel(slog.LevelError, ttl)
+			},
+			wantSet:   slog.LevelError,
+			wantAfter: slog.LevelInfo,
+		},
+		{
+			name: "global without ttl cancels revert",
+			set: func(c *LevelController) {
+				c.SetLevel(slog.LevelDebug, ttl)
+				c.SetLevel(slog.LevelWarn, 0)
+			},
+			wantSet:   slog.LevelWarn,
+			wantAfter: slog.LevelWarn,
+		},
+		{
+			name:      "named revert deletes absent override",
+			set:       func(c *LevelController) { c.SetNamed("orders", slog.LevelDebug, ttl) },
+			logger:    "orders",
+			wantSet:   slog.LevelDebug,
+			wantAfter: slog.LevelInfo,
+		},
+		{
+			name: "named revert restores previous override",
+			set: func(c *LevelController) {
+				c.SetNamed("orders", slog.LevelWarn, 0)
+				c.SetNamed("orders", slog.LevelDebug, ttl)
+			},
+			logger:    "orders",
+			wantSet:   slog.LevelDebug,
+			wantAfter: slog.LevelWarn,
+		},
+		{
+			name: "named extend keeps original value",
+			set: func(c *LevelController) {
+				c.SetNamed("orders", slog.LevelWarn, 0)
+				c.SetNamed("orders", slog.LevelDebug, ttl)
+				c.SetNamed("orders", slog.LevelError, ttl)
+			},
+			logger:    "orders",
+			wantSet:   slog.LevelError,
+			wantAfter: slog.LevelWarn,
+		},
+		{
+			name: "named without ttl cancels revert",
+			set: func(c *LevelController) {
+				c.SetNamed("orders", slog.LevelDebug, ttl)
+				c.SetNamed("orders", slog.LevelError, 0)
+			},
+			logger:    "orders",
+			wantSet:   slog.LevelError,
+			wantAfter: slog.LevelError,
+		},
+		{
+			name: "reset cancels revert",
+			set: func(c *LevelController) {
+				c.SetNamed("orders", slog.LevelDebug, ttl)
+				c.ResetNamed("orders")
+				c.SetNamed("orders", slog.LevelWarn, 0)
+			},
+			logger:    "orders",
+			wantSet:   slog.LevelWarn,
+			wantAfter: slog.LevelWarn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			c := NewLevelController(slog.LevelInfo)
+			tt.set(c)
+			if got := c.LevelFor(tt.logger); got != tt.wantSet {
+				t.Errorf("level after set = %v, want %v", got, tt.wantSet)
+			}
+
+			time.Sleep(5 * ttl)
+			if got := c.LevelFor(tt.logger); got != tt.wantAfter {
+				t.Errorf("level after ttl = %v, want %v", got, tt.wantAfter)
+			}
+			state := c.State()
+			if state.ExpiresAt != nil {
+				t.Errorf("global revert still scheduled: %v", state.ExpiresAt)
+			}
+			for name, e := range state.Loggers {
+				if e.ExpiresAt != nil {
+					t.Errorf("revert for %q still scheduled: %v", name, e.ExpiresAt)
+				}
+			}
+		})
+	}
+}
+
+func TestLevelForInheritance(t *testing.T) {
+	c := NewLevelController(slog.LevelInfo)
+	c.SetNamed("orders", slog.LevelDebug, 0)
+	c.SetNamed("orders.repo", slog.LevelError, 0)
+
+	tests := []struct {
+		logger string
+		want   slog.Level
+	}{
+		{"", slog.LevelInfo},
+		{"orders", slog.LevelDebug},
+		{"orders.api", slog.LevelDebug},
+		{"orders.repo", slog.LevelError},
+		{"orders.repo.sql", slog.LevelError},
+		{"ordersx", slog.LevelInfo},
+		{"billing.orders", slog.LevelInfo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.logger, func(t *testing.T) {
+			if got := c.LevelFor(tt.logger); got != tt.want {
+				t.Errorf("LevelFor(%q) = %v, want %v", tt.logger, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(c *LevelController)
+		method     string
+		body       string
+		wantStatus int
+		// check проверяет состояние из ответа при 200
+		check func(t *testing.T, s LevelState)
+	}{
+		{
+			name:       "get",
+			setup:      func(c *LevelController) { c.SetNamed("orders", slog.LevelWarn, time.Hour) },
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, s LevelState) {
+				if s.Level != "INFO" || s.ExpiresAt != nil {
+					t.Errorf("global = %s, expires %v", s.Level, s.ExpiresAt)
+				}
+				if e := s.Loggers["orders"]; e.Level != "WARN" || e.ExpiresAt == nil {
+					t.Errorf("orders = %+v", e)
+				}
+			},
+		},
+		{
+			name:       "put global with ttl",
+			method:     http.MethodPut,
+			body:       `{"level":"debug","ttl":"15m"}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, s LevelState) {
+				if s.Level != "DEBUG" || s.ExpiresAt == nil {
+					t.Errorf("global = %s, expires %v", s.Level, s.ExpiresAt)
+				}
+			},
+		},
+		{
+			name:       "put named",
+			method:     http.MethodPut,
+			body:       `{"logger":"orders","level":"WARN"}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, s LevelState) {
+				if e := s.Loggers["orders"]; e.Level != "WARN" || e.ExpiresAt != nil {
+					t.Errorf("orders = %+v", e)
+				}
+			},
+		},
+		{
+			name:       "put empty level resets named",
+			setup:      func(c *LevelController) { c.SetNamed("orders", slog.LevelDebug, time.Hour) },
+			method:     http.MethodPut,
+			body:       `{"logger":"orders","level":""}`,
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, s LevelState) {
+				if len(s.Loggers) != 0 {
+					t.Errorf("loggers = %v, want none", s.Loggers)
+				}
+			},
+		},
+		{name: "invalid level", method: http.MethodPut, body: `{"level":"verbose"}`, wantStatus: http.StatusBadRequest},
+		{name: "empty global level", method: http.MethodPut, body: `{"level":""}`, wantStatus: http.StatusBadRequest},
+		{name: "negative ttl", method: http.MethodPut, body: `{"level":"DEBUG","ttl":"-1m"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown field", method: http.MethodPut, body: `{"lvl":"DEBUG"}`, wantStatus: http.StatusBadRequest},
+		{name: "method not allowed", method: http.MethodPost, body: `{"level":"DEBUG"}`, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLevelController(slog.LevelInfo)
+			if tt.setup != nil {
+				tt.setup(c)
+			}
+			rec := httptest.NewRecorder()
+			c.Handler().ServeHTTP(rec, httptest.NewRequest(tt.method, "/debug/loglevel", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %q)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.check == nil {
+				return
+			}
+			var s LevelState
+			if err := json.Unmarshal(rec.Body.Bytes(), &s); err != nil {
+				t.Fatalf("decode %q: %v", rec.Body.String(), err)
+			}
+			tt.check(t, s)
+		})
+	}
+}
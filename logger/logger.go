@@ -2,19 +2,24 @@ package logger
 
 import (
 	"log/slog"
+	"math"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/grafana/loki-client-go/loki"
 	slogloki "github.com/samber/slog-loki/v3"
 	platformotel "github.com/vovanwin/platform/otel"
+	"github.com/vovanwin/platform/requestid"
 )
 
 // Options параметры для создания логгера.
 type Options struct {
-	// Level уровень логирования: DEBUG, INFO, WARN, ERROR
+	// Level начальный уровень логирования: DEBUG, INFO, WARN, ERROR.
+	// Меняется во время работы через LevelController (см. ControllerOf).
 	Level string
+	// NamedLevels начальные переопределения уровня для именованных логгеров (см. Named),
+	// напр. {"orders": "DEBUG"}. Неизвестный уровень — INFO.
+	NamedLevels map[string]string
 	// JSON если true — вывод в JSON (для прода), иначе цветной текст (для локальной разработки)
 	JSON bool
 	// LokiEnabled включить отправку логов в Loki
@@ -30,11 +35,18 @@ type Options struct {
 
 // NewLogger создаёт slog.Logger и устанавливает его как глобальный (slog.Default).
 // Второй возврат — функция-closer для Loki client. Если Loki выключен — no-op.
+// Уровень хранится в LevelController и меняется без перезапуска (см. ControllerOf, Named).
 //
 // Локально (JSON=false): цветной вывод через tint, время в читаемом формате, source для быстрого перехода в IDE.
 // Прод (JSON=true): структурированный JSON в stdout, без цветов, с source для трейсинга ошибок.
 func NewLogger(opts Options) (*slog.Logger, func()) {
-	level := parseLevel(opts.Level)
+	ctrl := NewLevelController(parseLevel(opts.Level))
+	for name, level := range opts.NamedLevels {
+		ctrl.SetNamed(name, parseLevel(level), 0)
+	}
+	// Console и Loki пропускают всё: уровень по имени логгера и отладочные запросы
+	// проверяет levelHandler
+	level := slog.Level(math.MinInt)
 
 	var consoleHandler slog.Handler
 	if opts.JSON {
@@ -79,21 +91,20 @@ func NewLogger(opts Options) (*slog.Logger, func()) {
 		handler = platformotel.NewTraceIDHandler(handler)
 	}
 
-	l := slog.New(handler)
+	// request_id из контекста добавляется всегда — он есть и без OTEL
+	handler = requestid.NewHandler(handler)
+
+	l := slog.New(&levelHandler{inner: handler, ctrl: ctrl, opts: &opts})
 	slog.SetDefault(l)
 
 	return l, closer
 }
 
+// parseLevel разбирает уровень из конфигурации; пустой или неизвестный — INFO.
 func parseLevel(s string) slog.Level {
-	switch strings.ToUpper(s) {
-	case "DEBUG":
-		return slog.LevelDebug
-	case "WARN":
-		return slog.LevelWarn
-	case "ERROR":
-		return slog.LevelError
-	default:
+	level, err := ParseLevel(s)
+	if err != nil {
 		return slog.LevelInfo
 	}
+	return level
 }
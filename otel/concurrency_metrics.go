@@ -0,0 +1,59 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+// ConcurrencyMetrics собирает метрики адаптивного ограничения конкурентности:
+//   - {appName}.concurrency.limit — текущий лимит одновременных запросов
+//   - {appName}.concurrency.inflight — запросы в обработке
+//   - {appName}.concurrency.shed.total — отброшенные при перегрузке запросы (route, priority)
+type ConcurrencyMetrics struct {
+	limit    otelmetric.Int64Gauge
+	inflight otelmetric.Int64UpDownCounter
+	shed     otelmetric.Int64Counter
+}
+
+// NewConcurrencyMetrics создаёт инструменты ограничителя конкурентности.
+func NewConcurrencyMetrics(appName string) *ConcurrencyMetrics {
+	meter := otel.Meter(appName)
+
+	limit, _ := meter.Int64Gauge(
+		appName+".concurrency.limit",
+		otelmetric.WithDescription("Current adaptive concurrency limit"),
+	)
+
+	inflight, _ := meter.Int64UpDownCounter(
+		appName+".concurrency.inflight",
+		otelmetric.WithDescription("Requests currently admitted by concurrency limiter"),
+	)
+
+	shed, _ := meter.Int64Counter(
+		appName+".concurrency.shed.total",
+		otelmetric.WithDescription("Requests rejected by concurrency limiter"),
+	)
+
+	return &ConcurrencyMetrics{limit: limit, inflight: inflight, shed: shed}
+}
+
+// SetLimit записывает текущий лимит.
+func (m *ConcurrencyMetrics) SetLimit(ctx context.Context, limit int) {
+	m.limit.Record(ctx, int64(limit))
+}
+
+// AddInflight изменяет число запросов в обработке на delta.
+func (m *ConcurrencyMetrics) AddInflight(ctx context.Context, delta int) {
+	m.inflight.Add(ctx, int64(delta))
+}
+
+// Shed учитывает отброшенный запрос.
+func (m *ConcurrencyMetrics) Shed(ctx context.Context, route, priority string) {
+	m.shed.Add(ctx, 1, otelmetric.WithAttributes(
+		attribute.String("route", route),
+		attribute.String("priority", priority),
+	))
+}
@@ -0,0 +1,313 @@
+package otel
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+)
+
+const (
+	// DebugLogHeader — заголовок запроса с токеном отладочного логирования по умолчанию.
+	DebugLogHeader = "X-Debug-Log"
+	// DebugLogBaggageKey — член baggage, в котором производный токен уходит в исходящие вызовы.
+	DebugLogBaggageKey = "debug_log"
+
+	defaultDebugLogMaxTTL       = time.Hour
+	defaultDebugLogPropagateTTL = time.Minute
+)
+
+// DebugLogConfig — отладочное логирование отдельных запросов: запрос с действительным токеном
+// в заголовке X-Debug-Log (или в baggage от вызывающего сервиса) логируется с уровнем DEBUG
+// во всех обработчиках, а его трейс сохраняется независимо от SampleRate.
+type DebugLogConfig struct {
+	// Header — HTTP заголовок и gRPC metadata с токеном. Пусто — X-Debug-Log.
+	Header string
+	// Tokens — разрешённые статические токены (allow-list). В исходящие вызовы не передаются.
+	Tokens []string `secret:"true"`
+	// Key — ключ HMAC для подписанных токенов со сроком действия (см. NewDebugLogToken).
+	// Без ключа отладочный режим не передаётся в исходящие вызовы.
+	Key []byte `secret:"true"`
+	// MaxTTL — максимальный срок действия подписанного токена: токен, истекающий позже
+	// now+MaxTTL, отклоняется. 0 — 1h.
+	MaxTTL time.Duration
+	// PropagateTTL — срок действия производного токена, который подписывается ключом Key
+	// и уходит в baggage исходящих вызовов вместо исходного. 0 — 1m.
+	PropagateTTL time.Duration
+}
+
+// DebugLog проверяет токены отладочного логирования на входе в сервис и переносит
+// признак в context и baggage.
+type DebugLog struct {
+	header       string
+	tokens       [][]byte
+	key          []byte
+	maxTTL       time.Duration
+	propagateTTL time.Duration
+}
+
+// NewDebugLog создаёт DebugLog. Нужен хотя бы один статический токен или ключ HMAC.
+func NewDebugLog(cfg DebugLogConfig) (*DebugLog, error) {
+	if len(cfg.Tokens) == 0 && len(cfg.Key) == 0 {
+		return nil, errors.New("debug log: Tokens or Key is required")
+	}
+
+	d := &DebugLog{
+		header:       http.CanonicalHeaderKey(cfg.Header),
+		key:          cfg.Key,
+		maxTTL:       cfg.MaxTTL,
+		propagateTTL: cfg.PropagateTTL,
+	}
+	if d.header == "" {
+		d.header = DebugLogHeader
+	}
+	if d.maxTTL <= 0 {
+		d.maxTTL = defaultDebugLogMaxTTL
+	}
+	if d.propagateTTL <= 0 {
+		d.propagateTTL = defaultDebugLogPropagateTTL
+	}
+	d.propagateTTL = min(d.propagateTTL, d.maxTTL)
+	for _, t := range cfg.Tokens {
+		if t == "" {
+			return nil, errors.New("debug log: empty token")
+		}
+		d.tokens = append(d.tokens, []byte(t))
+	}
+	return d, nil
+}
+
+// NewDebugLogToken создаёт подписанный токен, действующий ttl: "<unix expiry>.<HMAC-SHA256>".
+func NewDebugLogToken(key []byte, ttl time.Duration) string {
+	return debugLogToken(key, time.Now().Add(ttl))
+}
+
+func debugLogToken(key []byte, exp time.Time) string {
+	expiry := strconv.FormatInt(exp.Unix(), 10)
+	return expiry + "." + debugLogSign(key, expiry)
+}
+
+func debugLogSign(key []byte, expiry string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(expiry))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет токен: совпадение со статическим токеном или действующая подпись.
+func (d *DebugLog) Verify(token string) bool {
+	_, ok := d.verify(token)
+	return ok
+}
+
+// verify возвращает также срок действия подписанного токена; у статического он нулевой.
+func (d *DebugLog) verify(token string) (time.Time, bool) {
+	if token == "" {
+		return time.Time{}, false
+	}
+	for _, t := range d.tokens {
+		if subtle.ConstantTimeCompare(t, []byte(token)) == 1 {
+			return time.Time{}, true
+		}
+	}
+	if len(d.key) == 0 {
+		return time.Time{}, false
+	}
+
+	expiry, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	exp := time.Unix(unix, 0)
+	if time.Now().After(exp) || time.Until(exp) > d.maxTTL {
+		return time.Time{}, false
+	}
+	return exp, hmac.Equal([]byte(sig), []byte(debugLogSign(d.key, expiry)))
+}
+
+// propagated возвращает производный токен для исходящих вызовов: подписанный ключом Key,
+// действующий PropagateTTL, но не дольше исходного. Исходный токен дальше сервиса не уходит —
+// baggage получают и внешние системы. Без ключа — пустая строка.
+func (d *DebugLog) propagated(exp time.Time) string {
+	if len(d.key) == 0 {
+		return ""
+	}
+	if limit := time.Now().Add(d.propagateTTL); exp.IsZero() || exp.After(limit) {
+		exp = limit
+	}
+	return debugLogToken(d.key, exp)
+}
+
+// debugLogKey — ключ context с признаком отладочного логирования.
+type debugLogKey struct{}
+
+// ContextWithDebugLog включает отладочное логирование для context без проверки токена и без
+// передачи в исходящие вызовы — напр. для фоновой задачи, запущенной из отладочного запроса.
+func ContextWithDebugLog(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugLogKey{}, true)
+}
+
+// DebugLogEnabled сообщает, включено ли отладочное логирование для запроса. Признак ставят
+// только DebugLog на входе после проверки токена и ContextWithDebugLog — член baggage
+// от клиента сам по себе его не включает.
+func DebugLogEnabled(ctx context.Context) bool {
+	on, _ := ctx.Value(debugLogKey{}).(bool)
+	return on
+}
+
+// ingress включает признак для действительного токена и кладёт в baggage производный токен
+// для исходящих вызовов; исходный и недействительный члены baggage удаляет.
+func (d *DebugLog) ingress(ctx context.Context, token string, bag baggage.Baggage) (context.Context, baggage.Baggage) {
+	bag = bag.DeleteMember(DebugLogBaggageKey)
+	if exp, ok := d.verify(token); ok {
+		if derived := d.propagated(exp); derived != "" {
+			if m, err := baggage.NewMemberRaw(DebugLogBaggageKey, derived); err == nil {
+				bag, _ = bag.SetMember(m)
+			}
+		}
+		ctx = ContextWithDebugLog(ctx)
+	}
+	return baggage.ContextWithBaggage(ctx, bag), bag
+}
+
+// Middleware проверяет токен из заголовка или из baggage и переписывает заголовок baggage,
+// чтобы OTEL propagation дальше по цепочке видел только производный токен.
+// Должен стоять до OTEL middleware: тогда sampler уже видит признак и сохраняет трейс.
+func (d *DebugLog) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bag, _ := baggage.Parse(strings.Join(r.Header.Values("Baggage"), ","))
+			token := r.Header.Get(d.header)
+			if token == "" {
+				token = bag.Member(DebugLogBaggageKey).Value()
+			}
+			if token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, bag := d.ingress(r.Context(), token, bag)
+			r = r.WithContext(ctx)
+			r.Header.Del(d.header)
+			if bag.Len() > 0 {
+				r.Header.Set("Baggage", bag.String())
+			} else {
+				r.Header.Del("Baggage")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StatsHandler возвращает gRPC stats handler, который делает то же для входящих вызовов:
+// токен из metadata (имя заголовка в нижнем регистре) или из baggage. Регистрируется раньше
+// otelgrpc.NewServerHandler, чтобы тот извлёк уже очищенный baggage.
+func (d *DebugLog) StatsHandler() stats.Handler {
+	return &debugLogStats{d: d, md: strings.ToLower(d.header)}
+}
+
+type debugLogStats struct {
+	d  *DebugLog
+	md string
+}
+
+// TagRPC вызывается до interceptors и до создания спана otelgrpc.
+func (h *debugLogStats) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	bag, _ := baggage.Parse(strings.Join(md.Get("baggage"), ","))
+	var token string
+	if v := md.Get(h.md); len(v) > 0 {
+		token = v[0]
+	}
+	if token == "" {
+		token = bag.Member(DebugLogBaggageKey).Value()
+	}
+	if token == "" {
+		return ctx
+	}
+
+	ctx, bag = h.d.ingress(ctx, token, bag)
+	md = md.Copy()
+	delete(md, h.md)
+	if bag.Len() > 0 {
+		md.Set("baggage", bag.String())
+	} else {
+		delete(md, "baggage")
+	}
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+func (h *debugLogStats) HandleRPC(context.Context, stats.RPCStats) {}
+
+func (h *debugLogStats) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *debugLogStats) HandleConn(context.Context, stats.ConnStats) {}
+
+// outgoing добавляет производный токен в исходящие metadata, если запрос отладочный.
+func (d *DebugLog) outgoing(ctx context.Context) context.Context {
+	if !DebugLogEnabled(ctx) {
+		return ctx
+	}
+	if token := baggage.FromContext(ctx).Member(DebugLogBaggageKey).Value(); token != "" {
+		return metadata.AppendToOutgoingContext(ctx, strings.ToLower(d.header), token)
+	}
+	return ctx
+}
+
+// UnaryClientInterceptor передаёт производный токен отладочного запроса в metadata исходящих вызовов.
+// Нужен клиентам без OTEL propagation; с otelgrpc токен уходит в baggage сам.
+func (d *DebugLog) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(d.outgoing(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor — аналог UnaryClientInterceptor для стримов.
+func (d *DebugLog) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(d.outgoing(ctx), desc, cc, method, opts...)
+	}
+}
+
+// debugLogSampler сохраняет трейсы отладочных запросов независимо от базового sampler.
+type debugLogSampler struct {
+	base sdktrace.Sampler
+}
+
+// DebugLogSampler оборачивает sampler: спаны запросов с отладочным логированием сэмплируются всегда,
+// в том числе при SampleRate < 1 и несэмплированном родителе. InitTracer подключает его автоматически.
+func DebugLogSampler(base sdktrace.Sampler) sdktrace.Sampler {
+	return debugLogSampler{base: base}
+}
+
+func (s debugLogSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if p.ParentContext != nil && DebugLogEnabled(p.ParentContext) {
+		return sdktrace.SamplingResult{
+			Decision:   sdktrace.RecordAndSample,
+			Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+		}
+	}
+	return s.base.ShouldSample(p)
+}
+
+func (s debugLogSampler) Description() string {
+	return "DebugLogSampler{" + s.base.Description() + "}"
+}
@@ -0,0 +1,94 @@
+package otel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/stats"
+)
+
+// GRPCConnMetrics — gRPC stats handler с метриками соединений:
+//   - {appName}.grpc.connections.active — открытые соединения
+//   - {appName}.grpc.connections.opened.total — принятые соединения
+//   - {appName}.grpc.connections.closed.total — закрытые соединения (в т.ч. по MaxConnectionAge и idle)
+//   - {appName}.grpc.connection.duration — время жизни соединения (секунды)
+//
+// In-process соединения grpc-gateway (bufconn) не учитываются.
+type GRPCConnMetrics struct {
+	active   otelmetric.Int64UpDownCounter
+	opened   otelmetric.Int64Counter
+	closed   otelmetric.Int64Counter
+	duration otelmetric.Float64Histogram
+}
+
+// connBuckets — границы гистограммы времени жизни соединения: от секунды до суток.
+var connBuckets = []float64{1, 10, 60, 300, 600, 1800, 3600, 7200, 21600, 86400}
+
+// NewGRPCConnMetrics создаёт метрики соединений gRPC сервера.
+// Подключается через grpc.StatsHandler.
+func NewGRPCConnMetrics(appName string) *GRPCConnMetrics {
+	meter := otel.Meter(appName)
+
+	active, _ := meter.Int64UpDownCounter(
+		appName+".grpc.connections.active",
+		otelmetric.WithDescription("Open gRPC server connections"),
+	)
+
+	opened, _ := meter.Int64Counter(
+		appName+".grpc.connections.opened.total",
+		otelmetric.WithDescription("Accepted gRPC server connections"),
+	)
+
+	closed, _ := meter.Int64Counter(
+		appName+".grpc.connections.closed.total",
+		otelmetric.WithDescription("Closed gRPC server connections"),
+	)
+
+	duration, _ := meter.Float64Histogram(
+		appName+".grpc.connection.duration",
+		otelmetric.WithDescription("gRPC server connection lifetime"),
+		otelmetric.WithUnit("s"),
+		otelmetric.WithExplicitBucketBoundaries(connBuckets...),
+	)
+
+	return &GRPCConnMetrics{active: active, opened: opened, closed: closed, duration: duration}
+}
+
+// connStartKey — ключ context со временем открытия соединения.
+type connStartKey struct{}
+
+// TagConn запоминает время открытия соединения.
+func (m *GRPCConnMetrics) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	if info.RemoteAddr != nil && info.RemoteAddr.Network() == "bufconn" {
+		return ctx
+	}
+	return context.WithValue(ctx, connStartKey{}, time.Now())
+}
+
+// HandleConn учитывает открытие и закрытие соединения.
+func (m *GRPCConnMetrics) HandleConn(ctx context.Context, s stats.ConnStats) {
+	start, ok := ctx.Value(connStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+
+	switch s.(type) {
+	case *stats.ConnBegin:
+		m.active.Add(ctx, 1)
+		m.opened.Add(ctx, 1)
+	case *stats.ConnEnd:
+		m.active.Add(ctx, -1)
+		m.closed.Add(ctx, 1)
+		m.duration.Record(ctx, time.Since(start).Seconds())
+	}
+}
+
+// TagRPC не меняет context вызова.
+func (m *GRPCConnMetrics) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+// HandleRPC — вызовы не учитываются, только соединения.
+func (m *GRPCConnMetrics) HandleRPC(context.Context, stats.RPCStats) {}
@@ -0,0 +1,37 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+// RegisterLogLevelMetrics регистрирует gauge {appName}.log.level с текущими уровнями логирования:
+// значение — slog.Level (-4 DEBUG, 0 INFO, 4 WARN, 8 ERROR), label logger — имя логгера,
+// "root" для глобального уровня. levels вызывается при каждом сборе метрик и возвращает
+// глобальный уровень под пустым именем (см. logger.LevelController.Levels).
+func RegisterLogLevelMetrics(appName string, levels func() map[string]slog.Level) error {
+	meter := otel.Meter(appName)
+
+	_, err := meter.Int64ObservableGauge(
+		appName+".log.level",
+		otelmetric.WithDescription("Current log level (-4 = debug, 0 = info, 4 = warn, 8 = error)"),
+		otelmetric.WithInt64Callback(func(_ context.Context, o otelmetric.Int64Observer) error {
+			for name, level := range levels() {
+				if name == "" {
+					name = "root"
+				}
+				o.Observe(int64(level), otelmetric.WithAttributes(attribute.String("logger", name)))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("register log level metrics: %w", err)
+	}
+	return nil
+}
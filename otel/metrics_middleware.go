@@ -34,6 +34,17 @@ func (w *statusResponseWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
+func (w *statusResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap нужен http.ResponseController: снятие дедлайнов стримов gateway.
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // MetricsMiddleware возвращает HTTP middleware, который собирает per-route метрики:
 //   - {appName}.http.requests.total — счётчик запросов (method, route, status_code)
 //   - {appName}.http.errors.total — счётчик ошибок status >= 400 (method, route, status_code)
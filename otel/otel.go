@@ -103,7 +103,7 @@ func InitTracer(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, erro
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exporter),
 		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.sampleRate()))),
+		sdktrace.WithSampler(DebugLogSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.sampleRate())))),
 	)
 
 	otel.SetTracerProvider(tp)
@@ -0,0 +1,46 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+// RateLimitMetrics собирает метрики rate limiter:
+//   - {appName}.ratelimit.allowed.total — пропущенные запросы (route)
+//   - {appName}.ratelimit.rejected.total — отклонённые запросы (route)
+//
+// route — "METHOD /pattern" для HTTP или полный gRPC метод.
+type RateLimitMetrics struct {
+	allowed  otelmetric.Int64Counter
+	rejected otelmetric.Int64Counter
+}
+
+// NewRateLimitMetrics создаёт счётчики rate limiter.
+func NewRateLimitMetrics(appName string) *RateLimitMetrics {
+	meter := otel.Meter(appName)
+
+	allowed, _ := meter.Int64Counter(
+		appName+".ratelimit.allowed.total",
+		otelmetric.WithDescription("Requests allowed by rate limiter"),
+	)
+
+	rejected, _ := meter.Int64Counter(
+		appName+".ratelimit.rejected.total",
+		otelmetric.WithDescription("Requests rejected by rate limiter"),
+	)
+
+	return &RateLimitMetrics{allowed: allowed, rejected: rejected}
+}
+
+// Record учитывает решение rate limiter для одного запроса.
+func (m *RateLimitMetrics) Record(ctx context.Context, route string, allowed bool) {
+	attrs := otelmetric.WithAttributes(attribute.String("route", route))
+	if allowed {
+		m.allowed.Add(ctx, 1, attrs)
+		return
+	}
+	m.rejected.Add(ctx, 1, attrs)
+}
@@ -0,0 +1,50 @@
+package otel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelmetric "go.opentelemetry.io/otel/metric"
+)
+
+// ReadinessMetrics собирает метрики readiness-проверок зависимостей:
+//   - {appName}.readiness.check.status — 1 если проверка прошла, 0 если нет (check)
+//   - {appName}.readiness.check.duration — гистограмма длительности проверки в секундах (check)
+type ReadinessMetrics struct {
+	status   otelmetric.Int64Gauge
+	duration otelmetric.Float64Histogram
+}
+
+// NewReadinessMetrics создаёт инструменты для readiness-проверок.
+func NewReadinessMetrics(appName string) *ReadinessMetrics {
+	meter := otel.Meter(appName)
+
+	status, _ := meter.Int64Gauge(
+		appName+".readiness.check.status",
+		otelmetric.WithDescription("Readiness check status (1 = ok, 0 = failed)"),
+	)
+
+	duration, _ := meter.Float64Histogram(
+		appName+".readiness.check.duration",
+		otelmetric.WithDescription("Readiness check duration in seconds"),
+		otelmetric.WithUnit("s"),
+		otelmetric.WithExplicitBucketBoundaries(durationBuckets...),
+	)
+
+	return &ReadinessMetrics{status: status, duration: duration}
+}
+
+// Record записывает результат одной проверки.
+func (m *ReadinessMetrics) Record(ctx context.Context, check string, err error, elapsed time.Duration) {
+	attrs := otelmetric.WithAttributes(attribute.String("check", check))
+
+	var value int64 = 1
+	if err != nil {
+		value = 0
+	}
+
+	m.status.Record(ctx, value, attrs)
+	m.duration.Record(ctx, elapsed.Seconds(), attrs)
+}
@@ -0,0 +1,79 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	otelmetric "go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcRecovery превращает панику gRPC обработчика в ошибку INTERNAL.
+type grpcRecovery struct {
+	panicsTotal otelmetric.Int64Counter
+}
+
+func newGRPCRecovery(appName string) *grpcRecovery {
+	meter := otel.Meter(appName)
+	panicsTotal, _ := meter.Int64Counter(
+		appName+".grpc.panics.total",
+		otelmetric.WithDescription("Total number of recovered gRPC panics"),
+	)
+	return &grpcRecovery{panicsTotal: panicsTotal}
+}
+
+// recover записывает stack trace в спан, инкрементит счётчик и логирует панику.
+// Клиент получает INTERNAL без подробностей паники.
+func (g *grpcRecovery) recover(ctx context.Context, method string, rec any) error {
+	stack := debug.Stack()
+
+	span := trace.SpanFromContext(ctx)
+	span.SetStatus(otelcodes.Error, fmt.Sprintf("panic: %v", rec))
+	span.SetAttributes(attribute.String("panic.stack", string(stack)))
+
+	g.panicsTotal.Add(ctx, 1, otelmetric.WithAttributes(attribute.String("method", method)))
+
+	slog.ErrorContext(ctx, "panic recovered",
+		slog.Any("panic", rec),
+		slog.String("stack", string(stack)),
+		slog.String("method", method),
+	)
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+// RecoveryUnaryInterceptor ловит панику в gRPC unary handler, записывает stack trace в спан,
+// инкрементит счётчик {appName}.grpc.panics.total (label method) и возвращает INTERNAL.
+// Работает и без OTEL провайдеров: метрика и спан тогда no-op, лог пишется всегда.
+func RecoveryUnaryInterceptor(appName string) grpc.UnaryServerInterceptor {
+	g := newGRPCRecovery(appName)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				resp, err = nil, g.recover(ctx, info.FullMethod, rec)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// RecoveryStreamInterceptor — аналог RecoveryUnaryInterceptor для стримов.
+func RecoveryStreamInterceptor(appName string) grpc.StreamServerInterceptor {
+	g := newGRPCRecovery(appName)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				err = g.recover(ss.Context(), info.FullMethod, rec)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,107 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testServerStream struct {
+	grpc.ServerStream
+}
+
+func (testServerStream) Context() context.Context { return context.Background() }
+
+func TestRecoveryInterceptors(t *testing.T) {
+	const method = "/orders.OrderService/Get"
+
+	tests := []struct {
+		name     string
+		call     func(unary grpc.UnaryServerInterceptor, stream grpc.StreamServerInterceptor) error
+		wantCode codes.Code
+		// wantPanics — значение счётчика panics.total после вызова
+		wantPanics int64
+	}{
+		{"unary ok", func(u grpc.UnaryServerInterceptor, _ grpc.StreamServerInterceptor) error {
+			_, err := u(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+				func(context.Context, any) (any, error) { return "ok", nil })
+			return err
+		}, codes.OK, 0},
+		{"unary error passes through", func(u grpc.UnaryServerInterceptor, _ grpc.StreamServerInterceptor) error {
+			_, err := u(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+				func(context.Context, any) (any, error) { return nil, status.Error(codes.NotFound, "no order") })
+			return err
+		}, codes.NotFound, 0},
+		{"unary panic", func(u grpc.UnaryServerInterceptor, _ grpc.StreamServerInterceptor) error {
+			_, err := u(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+				func(context.Context, any) (any, error) { panic("nil map") })
+			return err
+		}, codes.Internal, 1},
+		{"unary panic with error", func(u grpc.UnaryServerInterceptor, _ grpc.StreamServerInterceptor) error {
+			_, err := u(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+				func(context.Context, any) (any, error) { panic(errors.New("db closed")) })
+			return err
+		}, codes.Internal, 1},
+		{"stream panic", func(_ grpc.UnaryServerInterceptor, s grpc.StreamServerInterceptor) error {
+			return s(nil, testServerStream{}, &grpc.StreamServerInfo{FullMethod: method},
+				func(any, grpc.ServerStream) error { panic("nil map") })
+		}, codes.Internal, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := sdkmetric.NewManualReader()
+			prevProvider := otel.GetMeterProvider()
+			otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+			var logs bytes.Buffer
+			prevLog := slog.Default()
+			slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+			t.Cleanup(func() {
+				otel.SetMeterProvider(prevProvider)
+				slog.SetDefault(prevLog)
+			})
+
+			err := tt.call(RecoveryUnaryInterceptor("app"), RecoveryStreamInterceptor("app"))
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %v (%v), want %v", code, err, tt.wantCode)
+			}
+			if tt.wantCode == codes.Internal && status.Convert(err).Message() != "internal error" {
+				t.Errorf("message = %q, panic details leaked to the client", status.Convert(err).Message())
+			}
+
+			var rm metricdata.ResourceMetrics
+			if err := reader.Collect(context.Background(), &rm); err != nil {
+				t.Fatalf("collect: %v", err)
+			}
+			if got := panicsTotal(rm); got != tt.wantPanics {
+				t.Errorf("app.grpc.panics.total = %d, want %d", got, tt.wantPanics)
+			}
+			if logged := strings.Contains(logs.String(), "panic recovered"); logged != (tt.wantPanics > 0) {
+				t.Errorf("panic logged = %v: %s", logged, logs.String())
+			}
+		})
+	}
+}
+
+func panicsTotal(rm metricdata.ResourceMetrics) int64 {
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "app.grpc.panics.total" {
+				for _, dp := range sum.DataPoints {
+					total += dp.Value
+				}
+			}
+		}
+	}
+	return total
+}
@@ -24,7 +24,7 @@ func (h *TraceIDHandler) Enabled(ctx context.Context, level slog.Level) bool {
 
 func (h *TraceIDHandler) Handle(ctx context.Context, record slog.Record) error {
 	span := trace.SpanFromContext(ctx)
-	if span.SpanContext().IsValid() {
+	if span.SpanContext().IsValid() && !hasAttr(record, "trace_id") {
 		record.AddAttrs(
 			slog.String("trace_id", span.SpanContext().TraceID().String()),
 			slog.String("span_id", span.SpanContext().SpanID().String()),
@@ -40,3 +40,13 @@ func (h *TraceIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 func (h *TraceIDHandler) WithGroup(name string) slog.Handler {
 	return &TraceIDHandler{inner: h.inner.WithGroup(name)}
 }
+
+// hasAttr проверяет, добавлен ли атрибут в запись явно (напр. логирующим gRPC interceptor).
+func hasAttr(record slog.Record, key string) bool {
+	found := false
+	record.Attrs(func(a slog.Attr) bool {
+		found = a.Key == key
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,76 @@
+package requestid
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name string
+		md   metadata.MD
+		// want — ожидаемый ID; пусто — сгенерированный UUID
+		want string
+	}{
+		{"from metadata", metadata.Pairs(MetadataKey, "req-42"), "req-42"},
+		{"no metadata", nil, ""},
+		{"invalid id", metadata.Pairs(MetadataKey, "bad id"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			var got string
+			_, err := UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/orders.OrderService/Get"},
+				func(ctx context.Context, _ any) (any, error) {
+					got = FromContext(ctx)
+					return nil, nil
+				})
+			if err != nil {
+				t.Fatalf("interceptor: %v", err)
+			}
+			if tt.want != "" && got != tt.want || tt.want == "" && !uuidRe.MatchString(got) {
+				t.Errorf("id = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want []string
+	}{
+		{"propagates context id", NewContext(context.Background(), "req-42"), []string{"req-42"}},
+		{"no id", context.Background(), nil},
+		{
+			"explicit metadata wins",
+			metadata.AppendToOutgoingContext(NewContext(context.Background(), "req-42"), MetadataKey, "explicit"),
+			[]string{"explicit"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := UnaryClientInterceptor()(tt.ctx, "/orders.OrderService/Get", nil, nil, nil,
+				func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+					md, _ := metadata.FromOutgoingContext(ctx)
+					got = md.Get(MetadataKey)
+					return nil
+				})
+			if err != nil {
+				t.Fatalf("interceptor: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("outgoing %s = %v, want %v", MetadataKey, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package requestid
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Handler оборачивает slog.Handler, добавляя request_id из контекста в каждую запись.
+type Handler struct {
+	inner slog.Handler
+}
+
+// NewHandler создаёт handler, добавляющий request_id к логам.
+func NewHandler(inner slog.Handler) *Handler {
+	return &Handler{inner: inner}
+}
+
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	if id := FromContext(ctx); id != "" && !hasAttr(record, LogKey) {
+		record.AddAttrs(slog.String(LogKey, id))
+	}
+	return h.inner.Handle(ctx, record)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{inner: h.inner.WithAttrs(attrs)}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{inner: h.inner.WithGroup(name)}
+}
+
+// hasAttr проверяет, добавлен ли атрибут в запись явно (напр. SlogRequestLogger).
+func hasAttr(record slog.Record, key string) bool {
+	found := false
+	record.Attrs(func(a slog.Attr) bool {
+		found = a.Key == key
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,42 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		attrs []any
+		want  string
+		// wantCount — сколько раз request_id встречается в записи
+		wantCount int
+	}{
+		{"adds id from context", NewContext(context.Background(), "req-42"), nil, "request_id=req-42", 1},
+		{"no id in context", context.Background(), nil, "", 0},
+		{"explicit attribute not duplicated", NewContext(context.Background(), "req-42"),
+			[]any{slog.String(LogKey, "req-42")}, "request_id=req-42", 1},
+		{"explicit attribute after others", NewContext(context.Background(), "req-42"),
+			[]any{slog.Int("status", 200), slog.String(LogKey, "req-42")}, "request_id=req-42", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log := slog.New(NewHandler(slog.NewTextHandler(&buf, nil)))
+			log.InfoContext(tt.ctx, "request", tt.attrs...)
+
+			out := buf.String()
+			if n := strings.Count(out, LogKey+"="); n != tt.wantCount {
+				t.Errorf("%s appears %d times, want %d: %s", LogKey, n, tt.wantCount, out)
+			}
+			if tt.want != "" && !strings.Contains(out, tt.want) {
+				t.Errorf("record %s does not contain %s", out, tt.want)
+			}
+		})
+	}
+}
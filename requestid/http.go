@@ -0,0 +1,18 @@
+package requestid
+
+import "net/http"
+
+// Middleware принимает X-Request-ID из запроса или генерирует новый, кладёт его в context,
+// в заголовок запроса (чтобы gateway передал его в gRPC метаданные) и в заголовок ответа.
+func Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := ensure(r.Header.Get(Header))
+
+			r.Header.Set(Header, id)
+			w.Header().Set(Header, id)
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+		})
+	}
+}
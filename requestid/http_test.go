@@ -0,0 +1,52 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var uuidRe = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		// wantSame — входящий ID принимается как есть, иначе генерируется новый
+		wantSame bool
+	}{
+		{"valid id", "req-42", true},
+		{"no header", "", false},
+		{"too long", strings.Repeat("a", maxLen+1), false},
+		{"max length", strings.Repeat("a", maxLen), true},
+		{"control character", "req\x01", false},
+		{"space", "req 42", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxID, reqHeader string
+			h := Middleware()(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				ctxID = FromContext(r.Context())
+				reqHeader = r.Header.Get(Header)
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.incoming != "" {
+				r.Header.Set(Header, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			if tt.wantSame && ctxID != tt.incoming {
+				t.Errorf("context id = %q, want %q", ctxID, tt.incoming)
+			}
+			if !tt.wantSame && !uuidRe.MatchString(ctxID) {
+				t.Errorf("context id = %q, want a generated UUID", ctxID)
+			}
+			if reqHeader != ctxID || rec.Header().Get(Header) != ctxID {
+				t.Errorf("request header %q, response header %q, want %q", reqHeader, rec.Header().Get(Header), ctxID)
+			}
+		})
+	}
+}
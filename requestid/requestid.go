@@ -0,0 +1,71 @@
+// Package requestid — сквозной идентификатор запроса для HTTP, gRPC и логов.
+//
+// ID принимается из заголовка X-Request-ID (метаданных x-request-id) или генерируется,
+// хранится в context, возвращается клиенту и добавляется в каждую запись лога.
+// В отличие от trace_id, он есть всегда — даже если трейс отброшен сэмплированием или OTEL выключен.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	// Header — HTTP заголовок с идентификатором запроса.
+	Header = "X-Request-ID"
+	// MetadataKey — ключ gRPC метаданных с идентификатором запроса.
+	MetadataKey = "x-request-id"
+	// LogKey — имя атрибута в логах.
+	LogKey = "request_id"
+
+	// maxLen — максимальная длина принимаемого от клиента ID, более длинные заменяются новым.
+	maxLen = 128
+)
+
+type ctxKey struct{}
+
+// NewContext возвращает копию ctx с идентификатором запроса.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext возвращает идентификатор запроса из ctx или пустую строку.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// New генерирует случайный идентификатор в формате UUID v4.
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
+
+// ensure возвращает входящий ID, если он допустим, иначе генерирует новый.
+// Допустимы непустые строки до maxLen печатных ASCII символов — ID попадает в заголовки и логи.
+func ensure(id string) string {
+	if id == "" || len(id) > maxLen {
+		return New()
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return New()
+		}
+	}
+	return id
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/vovanwin/platform/auth"
+	platformerrors "github.com/vovanwin/platform/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// initAuth создаёт auth.Authenticator и подключает его interceptors к gRPC серверу, а middleware —
+// к grpc-gateway. PublicPaths проверяются только в HTTP middleware, поэтому оно стоит перед всеми
+// маршрутами gateway, в том числе идущими через in-process соединение и добавленными через HandlePath.
+func (s *Server) initAuth(ctx context.Context, log *slog.Logger) error {
+	if s.authCfg == nil {
+		if s.policyCfg != nil {
+			return errors.New("WithAuthPolicy requires WithAuth")
+		}
+		return nil
+	}
+
+	a, err := auth.New(ctx, *s.authCfg, log)
+	if err != nil {
+		return fmt.Errorf("init auth: %w", err)
+	}
+	s.auth = a
+
+	s.appendInterceptors(serverInterceptors{s.authUnaryInterceptor(), s.authStreamInterceptor()})
+	s.gatewayMiddleware = append(s.gatewayMiddleware, gatewayMiddlewareFrom(a.HTTPMiddleware()))
+
+	return s.initAuthPolicy(log)
+}
+
+// publicPathMetadataKey — метаданные in-process вызова gateway по пути из auth.Config.PublicPaths.
+// Выставляются только клиентскими interceptors in-process соединения и читаются только у вызовов
+// через него, поэтому подделать их заголовком REST запроса нельзя.
+const publicPathMetadataKey = "x-platform-public-path"
+
+// authUnaryInterceptor проверяет токен gRPC вызова. Вызовы gateway через in-process соединение
+// уже прошли HTTP middleware с учётом PublicPaths, поэтому токен в них не обязателен:
+// действительный токен кладёт Claims в context, без него обработчик вызывается без Claims.
+func (s *Server) authUnaryInterceptor() grpc.UnaryServerInterceptor {
+	required := s.auth.UnaryServerInterceptor()
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if isInProcess(ctx) {
+			return handler(s.inProcessAuthContext(ctx), req)
+		}
+		return required(ctx, req, info, handler)
+	}
+}
+
+// authStreamInterceptor — аналог authUnaryInterceptor для стримов.
+func (s *Server) authStreamInterceptor() grpc.StreamServerInterceptor {
+	required := s.auth.StreamServerInterceptor()
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isInProcess(ss.Context()) {
+			return handler(srv, &contextStream{ServerStream: ss, ctx: s.inProcessAuthContext(ss.Context())})
+		}
+		return required(srv, ss, info, handler)
+	}
+}
+
+// inProcessAuthContext кладёт в context in-process вызова Claims из необязательного токена и отметку
+// публичного пути, с которой auth.Policy пропускает вызов.
+func (s *Server) inProcessAuthContext(ctx context.Context) context.Context {
+	ctx = s.auth.OptionalContext(ctx)
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(publicPathMetadataKey)) > 0 {
+		ctx = auth.NewPublicPathContext(ctx)
+	}
+	return ctx
+}
+
+// publicPathUnaryClientInterceptor передаёт отметку auth.IsPublicPathContext из HTTP запроса
+// в метаданные in-process вызова. Одноимённые метаданные из заголовков запроса удаляются.
+func publicPathUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withPublicPathMetadata(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// publicPathStreamClientInterceptor — аналог publicPathUnaryClientInterceptor для стримов.
+func publicPathStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withPublicPathMetadata(ctx), desc, cc, method, opts...)
+	}
+}
+
+func withPublicPathMetadata(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	delete(md, publicPathMetadataKey)
+	if auth.IsPublicPathContext(ctx) {
+		md.Set(publicPathMetadataKey, "1")
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// contextStream подменяет context стрима.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// initAuthPolicy подключает политику авторизации после аутентификации.
+func (s *Server) initAuthPolicy(log *slog.Logger) error {
+	if s.policyCfg == nil {
+		return nil
+	}
+
+	p, err := s.auth.NewPolicy(*s.policyCfg)
+	if err != nil {
+		return err
+	}
+	s.warnUnusedPolicyRules(log, p)
+
+	s.appendInterceptors(serverInterceptors{p.UnaryServerInterceptor(), p.StreamServerInterceptor()})
+	if len(s.gatewayRegistrators) > 0 {
+		s.gatewayMiddleware = append(s.gatewayMiddleware, s.policyGatewayMiddleware(p))
+	}
+	return nil
+}
+
+// warnUnusedPolicyRules предупреждает о правилах, которые не покрывают ни один зарегистрированный
+// метод — обычно это опечатка в имени сервиса или метода.
+func (s *Server) warnUnusedPolicyRules(log *slog.Logger, p *auth.Policy) {
+	methods := s.registeredMethods()
+	for _, pattern := range p.Patterns() {
+		used := false
+		for _, m := range methods {
+			if auth.Matches(pattern, m) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			log.Warn("Правило авторизации не покрывает ни один gRPC метод", slog.String("rule", pattern))
+		}
+	}
+}
+
+// policyGatewayMiddleware применяет политику к REST запросам, которые gateway передаёт сервисам
+// напрямую. gRPC метод определяется по маршруту из аннотаций google.api.http; маршруты без
+// gRPC метода (напр. mux.HandlePath) политикой не проверяются, как и запросы по PublicPaths.
+func (s *Server) policyGatewayMiddleware(p *auth.Policy) runtime.Middleware {
+	return func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			if method, ok := s.gatewayRoutes[gatewayRoute(r)]; ok {
+				if err := p.Authorize(r.Context(), method); err != nil {
+					platformerrors.WriteProblem(w, platformerrors.NewProblem(r.Context(), w, r, err))
+					return
+				}
+			}
+			next(w, r, pathParams)
+		}
+	}
+}
+
+// gatewayMiddlewareFrom адаптирует обычный HTTP middleware к middleware grpc-gateway.
+func gatewayMiddlewareFrom(mw func(http.Handler) http.Handler) runtime.Middleware {
+	return func(next runtime.HandlerFunc) runtime.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+			mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next(w, r, pathParams)
+			})).ServeHTTP(w, r)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/vovanwin/platform/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+var testAuthKey = []byte("test-secret")
+
+func testToken(t *testing.T, sub string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": sub,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(testAuthKey)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+// bufconnAddr — адрес in-process соединения, как у bufconn.
+type bufconnAddr struct{}
+
+func (bufconnAddr) Network() string { return "bufconn" }
+func (bufconnAddr) String() string  { return "bufconn" }
+
+func TestAuthInterceptorInProcess(t *testing.T) {
+	s := newServer(Config{}, WithAuth(auth.Config{Keys: map[string]any{"": testAuthKey}}))
+	if err := s.initAuth(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatalf("initAuth: %v", err)
+	}
+	interceptor := s.authUnaryInterceptor()
+
+	handler := func(ctx context.Context, _ any) (any, error) {
+		if c, ok := auth.FromContext(ctx); ok {
+			return c.Subject, nil
+		}
+		return "", nil
+	}
+
+	tests := []struct {
+		name      string
+		inProcess bool
+		token     string
+		wantCode  codes.Code
+		wantSub   string
+	}{
+		{"tcp without token", false, "", codes.Unauthenticated, ""},
+		{"tcp with token", false, testToken(t, "user-1"), codes.OK, "user-1"},
+		// Запрос прошёл HTTP middleware: публичный путь без токена
+		{"in-process without token", true, "", codes.OK, ""},
+		{"in-process with token", true, testToken(t, "user-1"), codes.OK, "user-1"},
+		{"in-process with bad token", true, "abc", codes.OK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.inProcess {
+				ctx = peer.NewContext(ctx, &peer.Peer{Addr: bufconnAddr{}})
+			}
+			if tt.token != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+tt.token))
+			}
+
+			resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/orders.OrderService/Get"}, handler)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %v, want %v (err = %v)", code, tt.wantCode, err)
+			}
+			if err == nil && resp != tt.wantSub {
+				t.Errorf("claims subject = %v, want %q", resp, tt.wantSub)
+			}
+		})
+	}
+}
+
+func TestAuthGatewayPublicPaths(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := newServer(Config{Host: "127.0.0.1", GRPCPort: "0", HTTPPort: "0"},
+		WithAuth(auth.Config{
+			Keys:        map[string]any{"": testAuthKey},
+			PublicPaths: []string{"/v1/public/*"},
+		}),
+		WithGatewayConnRegistrator(func(_ context.Context, mux *runtime.ServeMux, _ *grpc.ClientConn) error {
+			ok := func(w http.ResponseWriter, _ *http.Request, _ map[string]string) { w.WriteHeader(http.StatusOK) }
+			if err := mux.HandlePath(http.MethodGet, "/v1/public/ping", ok); err != nil {
+				return err
+			}
+			return mux.HandlePath(http.MethodGet, "/v1/private", ok)
+		}),
+	)
+	s.initStartup()
+	if err := s.initAuth(context.Background(), log); err != nil {
+		t.Fatalf("initAuth: %v", err)
+	}
+	if err := s.initGRPC(log); err != nil {
+		t.Fatalf("initGRPC: %v", err)
+	}
+	t.Cleanup(s.grpcServer.Stop)
+	if err := s.initHTTP(log); err != nil {
+		t.Fatalf("initHTTP: %v", err)
+	}
+	t.Cleanup(func() { _ = s.httpServer.Close() })
+
+	tests := []struct {
+		name       string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{"public without token", "/v1/public/ping", "", http.StatusOK},
+		{"private without token", "/v1/private", "", http.StatusUnauthorized},
+		{"private with token", "/v1/private", testToken(t, "user-1"), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "http://"+s.addrs.HTTP+tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestAuthPolicyPublicPaths проверяет, что политика не запрещает запросы по PublicPaths ни при
+// DenyByDefault, ни при правиле для метода — и у прямых маршрутов gateway, и у in-process.
+func TestAuthPolicyPublicPaths(t *testing.T) {
+	registerTickerProto(t)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// direct — маршрут /v1/ticks метода Ticker/Watch из аннотации, политика в gateway middleware
+	direct := WithGatewayRegistrator(func(_ context.Context, mux *runtime.ServeMux, _ *grpc.Server) error {
+		return mux.HandlePath(http.MethodGet, "/v1/ticks", func(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+	// inProcess — /v1/echo вызывает test.Echo/Get через in-process соединение, политика в interceptor
+	inProcess := WithGatewayConnRegistrator(func(_ context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error {
+		return mux.HandlePath(http.MethodGet, "/v1/echo", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+			ctx, _ := runtime.AnnotateContext(r.Context(), runtime.NewServeMux(), r, "/test.Echo/Get")
+			if err := conn.Invoke(ctx, "/test.Echo/Get", &emptypb.Empty{}, &emptypb.Empty{}); err != nil {
+				w.WriteHeader(runtime.HTTPStatusFromCode(status.Code(err)))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+	})
+	denyByDefault := auth.PolicyConfig{DenyByDefault: true}
+	adminOnly := auth.PolicyConfig{Rules: map[string]auth.Rule{
+		"/platform.test.Ticker/*": {Roles: []string{"admin"}},
+		"/test.Echo/*":            {Roles: []string{"admin"}},
+	}}
+
+	tests := []struct {
+		name   string
+		gw     Option
+		path   string
+		policy auth.PolicyConfig
+		public bool
+		token  string
+		// header — дополнительный заголовок запроса
+		header     string
+		wantStatus int
+	}{
+		{name: "direct deny by default public", gw: direct, path: "/v1/ticks", policy: denyByDefault, public: true, wantStatus: http.StatusOK},
+		{name: "direct rule public", gw: direct, path: "/v1/ticks", policy: adminOnly, public: true, wantStatus: http.StatusOK},
+		{name: "direct deny by default private", gw: direct, path: "/v1/ticks", policy: denyByDefault,
+			token: testToken(t, "user-1"), wantStatus: http.StatusForbidden},
+		{name: "direct rule private", gw: direct, path: "/v1/ticks", policy: adminOnly,
+			token: testToken(t, "user-1"), wantStatus: http.StatusForbidden},
+		{name: "in-process deny by default public", gw: inProcess, path: "/v1/echo", policy: denyByDefault, public: true, wantStatus: http.StatusOK},
+		{name: "in-process rule public", gw: inProcess, path: "/v1/echo", policy: adminOnly, public: true, wantStatus: http.StatusOK},
+		{name: "in-process deny by default private", gw: inProcess, path: "/v1/echo", policy: denyByDefault,
+			token: testToken(t, "user-1"), wantStatus: http.StatusForbidden},
+		{name: "in-process rule private", gw: inProcess, path: "/v1/echo", policy: adminOnly,
+			token: testToken(t, "user-1"), wantStatus: http.StatusForbidden},
+		// Отметку публичного пути нельзя передать заголовком: её выставляет только in-process клиент
+		{name: "in-process forged public metadata", gw: inProcess, path: "/v1/echo", policy: adminOnly,
+			token: testToken(t, "user-1"), header: "Grpc-Metadata-" + publicPathMetadataKey, wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authCfg := auth.Config{Keys: map[string]any{"": testAuthKey}}
+			if tt.public {
+				authCfg.PublicPaths = []string{tt.path}
+			}
+			s := newServer(Config{Host: "127.0.0.1", GRPCPort: "0", HTTPPort: "0"},
+				WithAuth(authCfg),
+				WithAuthPolicy(tt.policy),
+				WithGRPCRegistrator(registerTicker),
+				WithGRPCRegistrator(registerEcho),
+				tt.gw,
+			)
+			s.initStartup()
+			if err := s.initAuth(context.Background(), log); err != nil {
+				t.Fatalf("initAuth: %v", err)
+			}
+			if err := s.initGRPC(log); err != nil {
+				t.Fatalf("initGRPC: %v", err)
+			}
+			t.Cleanup(s.grpcServer.Stop)
+			if err := s.initHTTP(log); err != nil {
+				t.Fatalf("initHTTP: %v", err)
+			}
+			t.Cleanup(func() { _ = s.httpServer.Close() })
+
+			req, _ := http.NewRequest(http.MethodGet, "http://"+s.addrs.HTTP+tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			if tt.header != "" {
+				req.Header.Set(tt.header, "1")
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestAuthBeforeUserInterceptors(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	var gotSub string
+	userInterceptor := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if c, ok := auth.FromContext(ctx); ok {
+			gotSub = c.Subject
+		}
+		return handler(ctx, req)
+	}
+
+	s := newServer(Config{Host: "127.0.0.1", GRPCPort: "0"},
+		WithAuth(auth.Config{Keys: map[string]any{"": testAuthKey}}),
+		WithGRPCOptions(grpc.ChainUnaryInterceptor(userInterceptor)),
+		WithGRPCRegistrator(registerEcho),
+	)
+	if err := s.initAuth(context.Background(), log); err != nil {
+		t.Fatalf("initAuth: %v", err)
+	}
+	if err := s.initGRPC(log); err != nil {
+		t.Fatalf("initGRPC: %v", err)
+	}
+	t.Cleanup(s.grpcServer.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+testToken(t, "user-1"))
+
+	conn := dial(t, s.addrs.GRPC, insecure.NewCredentials())
+	if err := conn.Invoke(ctx, "/test.Echo/Get", &emptypb.Empty{}, &emptypb.Empty{}); err != nil {
+		t.Fatalf("Invoke: %v", err)
+	}
+	if gotSub != "user-1" {
+		t.Errorf("user interceptor claims subject = %q, want user-1", gotSub)
+	}
+}
+
+// registerEcho регистрирует сервис test.Echo с unary методом Get, возвращающим запрос.
+func registerEcho(s *grpc.Server) {
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Echo",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Get",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+				in := &emptypb.Empty{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				echo := func(context.Context, any) (any, error) { return in, nil }
+				if interceptor == nil {
+					return echo(ctx, in)
+				}
+				return interceptor(ctx, in, &grpc.UnaryServerInfo{FullMethod: "/test.Echo/Get"}, echo)
+			},
+		}},
+	}, struct{}{})
+}
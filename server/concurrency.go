@@ -0,0 +1,401 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	platformerrors "github.com/vovanwin/platform/errors"
+	platformotel "github.com/vovanwin/platform/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultConcurrencyInitialLimit     = 100
+	defaultConcurrencyMinLimit         = 10
+	defaultConcurrencyMaxLimit         = 1000
+	defaultConcurrencyWindow           = time.Second
+	defaultConcurrencyLatencyTolerance = 2.0
+	defaultConcurrencyBackoff          = 0.9
+	defaultConcurrencyCriticalReserve  = 0.1
+
+	// concurrencyMinSamples — минимум завершённых запросов в окне для пересчёта лимита.
+	concurrencyMinSamples = 10
+	// concurrencyBaselineDrift — доля, на которую базовая задержка подтягивается к средней за окно,
+	// если средняя выше. Позволяет лимиту восстановиться после устойчивого роста задержки (деплой, смена нагрузки).
+	concurrencyBaselineDrift = 0.05
+)
+
+// Priority — класс запроса при перегрузке.
+type Priority int
+
+const (
+	// PriorityNormal — обычные запросы, отбрасываются первыми.
+	PriorityNormal Priority = iota
+	// PriorityCritical — критичные запросы, могут занимать резерв Config.CriticalReserve лимита.
+	PriorityCritical
+	// PrioritySystem — health и reflection, не ограничиваются.
+	PrioritySystem
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityNormal:
+		return "normal"
+	case PriorityCritical:
+		return "critical"
+	case PrioritySystem:
+		return "system"
+	default:
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+}
+
+// ConcurrencyLimitConfig — настройки WithConcurrencyLimit.
+//
+// Лимит одновременных запросов подстраивается по AIMD: раз в Window средняя задержка сравнивается
+// с базовой (минимальной наблюдаемой). Если она выросла больше чем в LatencyTolerance раз или
+// запросы завершались по дедлайну — лимит умножается на Backoff, иначе при загрузке больше
+// половины лимита — увеличивается на sqrt(limit).
+type ConcurrencyLimitConfig struct {
+	// InitialLimit — стартовый лимит. 0 — 100.
+	InitialLimit int
+	// MinLimit и MaxLimit — границы лимита. 0 — 10 и 1000.
+	MinLimit int
+	MaxLimit int
+	// Window — период пересчёта лимита. 0 — 1s.
+	Window time.Duration
+	// LatencyTolerance — во сколько раз средняя задержка может превысить базовую без снижения лимита. 0 — 2.
+	LatencyTolerance float64
+	// Backoff — множитель снижения лимита (0..1). 0 — 0.9.
+	Backoff float64
+	// Critical — маршруты "METHOD /pattern" и полные gRPC методы с приоритетом PriorityCritical.
+	Critical []string
+	// CriticalReserve — доля лимита, доступная только критичным запросам (0..1). 0 — 0.1.
+	CriticalReserve float64
+}
+
+func (c ConcurrencyLimitConfig) initialLimit() int {
+	if c.InitialLimit > 0 {
+		return c.InitialLimit
+	}
+	return defaultConcurrencyInitialLimit
+}
+
+func (c ConcurrencyLimitConfig) minLimit() int {
+	if c.MinLimit > 0 {
+		return c.MinLimit
+	}
+	return defaultConcurrencyMinLimit
+}
+
+func (c ConcurrencyLimitConfig) maxLimit() int {
+	if c.MaxLimit > 0 {
+		return c.MaxLimit
+	}
+	return defaultConcurrencyMaxLimit
+}
+
+func (c ConcurrencyLimitConfig) window() time.Duration {
+	if c.Window > 0 {
+		return c.Window
+	}
+	return defaultConcurrencyWindow
+}
+
+func (c ConcurrencyLimitConfig) latencyTolerance() float64 {
+	if c.LatencyTolerance > 0 {
+		return c.LatencyTolerance
+	}
+	return defaultConcurrencyLatencyTolerance
+}
+
+func (c ConcurrencyLimitConfig) backoff() float64 {
+	if c.Backoff > 0 {
+		return c.Backoff
+	}
+	return defaultConcurrencyBackoff
+}
+
+func (c ConcurrencyLimitConfig) criticalReserve() float64 {
+	if c.CriticalReserve > 0 {
+		return c.CriticalReserve
+	}
+	return defaultConcurrencyCriticalReserve
+}
+
+func (c ConcurrencyLimitConfig) validate() error {
+	var errs []error
+	if c.minLimit() > c.maxLimit() {
+		errs = append(errs, fmt.Errorf("MinLimit %d is greater than MaxLimit %d", c.minLimit(), c.maxLimit()))
+	}
+	if c.Backoff < 0 || c.Backoff >= 1 {
+		errs = append(errs, fmt.Errorf("Backoff %v must be in [0, 1)", c.Backoff))
+	}
+	if c.CriticalReserve < 0 || c.CriticalReserve >= 1 {
+		errs = append(errs, fmt.Errorf("CriticalReserve %v must be in [0, 1)", c.CriticalReserve))
+	}
+	if c.LatencyTolerance != 0 && c.LatencyTolerance < 1 {
+		errs = append(errs, fmt.Errorf("LatencyTolerance %v must be at least 1", c.LatencyTolerance))
+	}
+	return errors.Join(errs...)
+}
+
+// concurrencyLimiter — общий для HTTP gateway и gRPC адаптивный ограничитель конкурентности.
+type concurrencyLimiter struct {
+	cfg      ConcurrencyLimitConfig
+	critical map[string]struct{}
+	metrics  *platformotel.ConcurrencyMetrics
+	// streams — маршруты gateway со streaming gRPC методом; заполняется в initHTTP до приёма запросов.
+	streams map[string]struct{}
+
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+	baseline time.Duration
+
+	// текущее окно измерений
+	windowStart time.Time
+	samples     int
+	latencySum  time.Duration
+	maxInflight int
+	dropped     bool
+}
+
+func newConcurrencyLimiter(cfg ConcurrencyLimitConfig) *concurrencyLimiter {
+	l := &concurrencyLimiter{
+		cfg:         cfg,
+		critical:    make(map[string]struct{}, len(cfg.Critical)),
+		limit:       float64(min(max(cfg.initialLimit(), cfg.minLimit()), cfg.maxLimit())),
+		windowStart: time.Now(),
+	}
+	for _, route := range cfg.Critical {
+		l.critical[route] = struct{}{}
+	}
+	return l
+}
+
+func (l *concurrencyLimiter) priority(route string) Priority {
+	if _, ok := l.critical[route]; ok {
+		return PriorityCritical
+	}
+	return PriorityNormal
+}
+
+// outcome — как завершённый запрос учитывается в окне AIMD.
+type outcome int
+
+const (
+	// outcomeOK — задержка запроса учитывается в средней за окно.
+	outcomeOK outcome = iota
+	// outcomeDropped — запрос завершился по дедлайну или паникой (признак перегрузки).
+	outcomeDropped
+	// outcomeIgnored — только освободить слот: длительность стримов не отражает нагрузку,
+	// и часовой Watch опустил бы лимит до MinLimit.
+	outcomeIgnored
+)
+
+// acquire занимает слот для запроса. Возвращает false, если запрос нужно отбросить.
+// release вызывается по завершении запроса, в том числе при панике обработчика (через defer).
+func (l *concurrencyLimiter) acquire(ctx context.Context, route string, p Priority) (release func(outcome), ok bool) {
+	if p == PrioritySystem {
+		return func(outcome) {}, true
+	}
+
+	l.mu.Lock()
+	threshold := l.limit
+	if p < PriorityCritical {
+		threshold = l.limit * (1 - l.cfg.criticalReserve())
+	}
+	if float64(l.inflight) >= math.Floor(threshold) {
+		l.mu.Unlock()
+		if l.metrics != nil {
+			l.metrics.Shed(ctx, route, p.String())
+		}
+		return nil, false
+	}
+	l.inflight++
+	l.maxInflight = max(l.maxInflight, l.inflight)
+	l.mu.Unlock()
+
+	if l.metrics != nil {
+		l.metrics.AddInflight(ctx, 1)
+	}
+
+	start := time.Now()
+	return func(o outcome) {
+		l.onSample(ctx, time.Since(start), o)
+	}, true
+}
+
+// onSample освобождает слот и учитывает задержку запроса; по окончании окна пересчитывает лимит.
+func (l *concurrencyLimiter) onSample(ctx context.Context, latency time.Duration, o outcome) {
+	l.mu.Lock()
+	l.inflight--
+	switch o {
+	case outcomeOK:
+		l.samples++
+		l.latencySum += latency
+	case outcomeDropped:
+		l.dropped = true
+	}
+
+	now := time.Now()
+	adjusted := false
+	if now.Sub(l.windowStart) >= l.cfg.window() && (l.samples >= concurrencyMinSamples || l.dropped) {
+		l.adjust()
+		l.windowStart = now
+		l.samples = 0
+		l.latencySum = 0
+		l.maxInflight = l.inflight
+		l.dropped = false
+		adjusted = true
+	}
+	limit := int(l.limit)
+	l.mu.Unlock()
+
+	if l.metrics != nil {
+		l.metrics.AddInflight(ctx, -1)
+		if adjusted {
+			l.metrics.SetLimit(ctx, limit)
+		}
+	}
+}
+
+// adjust пересчитывает лимит по итогам окна. Вызывается под mu.
+func (l *concurrencyLimiter) adjust() {
+	if l.samples == 0 {
+		// В окне только отброшенные запросы: задержки для базовой нет
+		l.limit = math.Max(float64(l.cfg.minLimit()), l.limit*l.cfg.backoff())
+		return
+	}
+	avg := l.latencySum / time.Duration(l.samples)
+
+	overloaded := l.dropped ||
+		(l.baseline > 0 && float64(avg) > float64(l.baseline)*l.cfg.latencyTolerance())
+
+	switch {
+	case overloaded:
+		l.limit = math.Max(float64(l.cfg.minLimit()), l.limit*l.cfg.backoff())
+	case float64(l.maxInflight)*2 >= l.limit:
+		l.limit = math.Min(float64(l.cfg.maxLimit()), l.limit+math.Sqrt(l.limit))
+	}
+
+	switch {
+	case l.baseline == 0 || avg < l.baseline:
+		l.baseline = avg
+	default:
+		l.baseline += time.Duration(float64(avg-l.baseline) * concurrencyBaselineDrift)
+	}
+}
+
+func overloadedError() error {
+	return platformerrors.Unavailable("server is overloaded").WithReason("OVERLOADED")
+}
+
+// gatewayMiddleware ограничивает конкурентность запросов grpc-gateway, при перегрузке отвечает 503.
+func (l *concurrencyLimiter) gatewayMiddleware(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		route := gatewayRoute(r)
+		release, ok := l.acquire(r.Context(), route, l.priority(route))
+		if !ok {
+			w.Header().Set("Retry-After", "1")
+			platformerrors.WriteProblem(w, platformerrors.NewProblem(r.Context(), w, r, overloadedError()))
+			return
+		}
+
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		o := outcomeDropped // паника обработчика: слот освобождается, паника идёт дальше
+		defer func() { release(o) }()
+
+		next(rw, r, pathParams)
+
+		o = outcomeOK
+		if _, ok := l.streams[route]; ok {
+			o = outcomeIgnored
+		} else if rw.status == http.StatusGatewayTimeout || errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+			o = outcomeDropped
+		}
+	}
+}
+
+// grpcPriority возвращает приоритет gRPC метода.
+func (l *concurrencyLimiter) grpcPriority(ctx context.Context, method string) Priority {
+	if isSystemMethod(method) || isInProcess(ctx) {
+		// Вызовы gateway через in-process соединение уже учтены в gateway middleware
+		return PrioritySystem
+	}
+	return l.priority(method)
+}
+
+// unaryInterceptor ограничивает конкурентность gRPC вызовов, при перегрузке отвечает UNAVAILABLE.
+func (l *concurrencyLimiter) unaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		release, ok := l.acquire(ctx, info.FullMethod, l.grpcPriority(ctx, info.FullMethod))
+		if !ok {
+			return nil, platformerrors.ToStatus(overloadedError()).Err()
+		}
+
+		o := outcomeDropped
+		defer func() { release(o) }()
+
+		resp, err := handler(ctx, req)
+		o = outcomeOK
+		if isDeadlineError(ctx, err) {
+			o = outcomeDropped
+		}
+		return resp, err
+	}
+}
+
+// streamInterceptor — аналог unaryInterceptor для стримов. Стрим занимает слот до завершения,
+// но его длительность и дедлайн не влияют на лимит: в окно AIMD попадает только паника.
+func (l *concurrencyLimiter) streamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		release, ok := l.acquire(ctx, info.FullMethod, l.grpcPriority(ctx, info.FullMethod))
+		if !ok {
+			return platformerrors.ToStatus(overloadedError()).Err()
+		}
+
+		o := outcomeDropped
+		defer func() { release(o) }()
+
+		err := handler(srv, ss)
+		o = outcomeIgnored
+		return err
+	}
+}
+
+func isDeadlineError(ctx context.Context, err error) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded
+}
+
+// initConcurrencyLimit подключает ограничитель конкурентности к grpc-gateway и gRPC серверу,
+// если задан WithConcurrencyLimit.
+func (s *Server) initConcurrencyLimit() error {
+	if s.concurrencyLimit == nil {
+		return nil
+	}
+	if err := s.concurrencyLimit.validate(); err != nil {
+		return fmt.Errorf("concurrency limit config: %w", err)
+	}
+
+	l := newConcurrencyLimiter(*s.concurrencyLimit)
+	s.concurrency = l
+	if s.otelCfg != nil {
+		l.metrics = platformotel.NewConcurrencyMetrics(s.otelCfg.ServiceName)
+		l.metrics.SetLimit(context.Background(), int(l.limit))
+	}
+
+	s.gatewayMiddleware = append(s.gatewayMiddleware, l.gatewayMiddleware)
+	s.appendInterceptors(serverInterceptors{l.unaryInterceptor(), l.streamInterceptor()})
+	return nil
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestConcurrencyLimitConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ConcurrencyLimitConfig
+		wantErr bool
+	}{
+		{"zero", ConcurrencyLimitConfig{}, false},
+		{"min above max", ConcurrencyLimitConfig{MinLimit: 50, MaxLimit: 20}, true},
+		{"backoff 1", ConcurrencyLimitConfig{Backoff: 1}, true},
+		{"negative reserve", ConcurrencyLimitConfig{CriticalReserve: -0.1}, true},
+		{"tolerance below 1", ConcurrencyLimitConfig{LatencyTolerance: 0.5}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiterAcquire(t *testing.T) {
+	tests := []struct {
+		name     string
+		priority Priority
+		// inflight — занятые слоты до вызова acquire при лимите 10 и резерве 0.1
+		inflight int
+		want     bool
+	}{
+		{"normal below threshold", PriorityNormal, 8, true},
+		{"normal in reserve", PriorityNormal, 9, false},
+		{"critical in reserve", PriorityCritical, 9, true},
+		{"critical at limit", PriorityCritical, 10, false},
+		{"system at limit", PrioritySystem, 10, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newConcurrencyLimiter(ConcurrencyLimitConfig{InitialLimit: 10, MinLimit: 1})
+			l.inflight = tt.inflight
+			if _, ok := l.acquire(context.Background(), "GET /v1/orders", tt.priority); ok != tt.want {
+				t.Errorf("acquire() = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiterAdjust(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseline time.Duration
+		latency  time.Duration
+		outcome  outcome
+		// maxInflight — пиковая загрузка окна при лимите 100
+		maxInflight int
+		want        float64
+	}{
+		{"latency spike", 10 * time.Millisecond, 50 * time.Millisecond, outcomeOK, 60, 90},
+		{"dropped", 10 * time.Millisecond, 10 * time.Millisecond, outcomeDropped, 60, 90},
+		{"busy and fast", 10 * time.Millisecond, 12 * time.Millisecond, outcomeOK, 60, 110},
+		{"idle", 10 * time.Millisecond, 12 * time.Millisecond, outcomeOK, 10, 100},
+		{"streams ignored", 10 * time.Millisecond, time.Hour, outcomeIgnored, 60, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newConcurrencyLimiter(ConcurrencyLimitConfig{InitialLimit: 100})
+			l.baseline = tt.baseline
+			l.inflight = concurrencyMinSamples
+			l.maxInflight = tt.maxInflight
+			for range concurrencyMinSamples - 1 {
+				l.onSample(context.Background(), tt.latency, tt.outcome)
+			}
+			// Последний запрос закрывает окно
+			l.windowStart = time.Now().Add(-time.Hour)
+			l.onSample(context.Background(), tt.latency, tt.outcome)
+
+			if l.limit != tt.want {
+				t.Errorf("limit = %v, want %v", l.limit, tt.want)
+			}
+			if l.inflight != 0 {
+				t.Errorf("inflight = %d, want 0", l.inflight)
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiterReleaseOnPanic(t *testing.T) {
+	mustPanic := func(t *testing.T, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Fatal("panic was not propagated")
+			}
+		}()
+		fn()
+	}
+
+	tests := []struct {
+		name string
+		call func(l *concurrencyLimiter)
+	}{
+		{"gateway", func(l *concurrencyLimiter) {
+			h := l.gatewayMiddleware(func(http.ResponseWriter, *http.Request, map[string]string) { panic("boom") })
+			h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/orders", nil), nil)
+		}},
+		{"unary", func(l *concurrencyLimiter) {
+			_, _ = l.unaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/orders.OrderService/Get"},
+				func(context.Context, any) (any, error) { panic("boom") })
+		}},
+		{"stream", func(l *concurrencyLimiter) {
+			_ = l.streamInterceptor()(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/orders.OrderService/Watch"},
+				func(any, grpc.ServerStream) error { panic("boom") })
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newConcurrencyLimiter(ConcurrencyLimitConfig{InitialLimit: 10, MinLimit: 1})
+			mustPanic(t, func() { tt.call(l) })
+
+			if l.inflight != 0 {
+				t.Errorf("inflight = %d after panic, want 0", l.inflight)
+			}
+			if !l.dropped {
+				t.Error("panic is not counted as dropped")
+			}
+		})
+	}
+}
+
+func TestConcurrencyLimiterShed(t *testing.T) {
+	l := newConcurrencyLimiter(ConcurrencyLimitConfig{InitialLimit: 1, MinLimit: 1, CriticalReserve: 0.5})
+	l.inflight = 1
+
+	rec := httptest.NewRecorder()
+	l.gatewayMiddleware(func(http.ResponseWriter, *http.Request, map[string]string) {
+		t.Fatal("handler called when overloaded")
+	})(rec, httptest.NewRequest(http.MethodGet, "/v1/orders", nil), nil)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("gateway: status = %d, Retry-After = %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+
+	_, err := l.unaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/orders.OrderService/Get"},
+		func(context.Context, any) (any, error) { return nil, nil })
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("unary: code = %v, want Unavailable", status.Code(err))
+	}
+
+	_, err = l.unaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"},
+		func(context.Context, any) (any, error) { return nil, nil })
+	if err != nil {
+		t.Errorf("health: err = %v, want nil", err)
+	}
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+// TestConcurrencyGatewayWriter проверяет, что обёртка ResponseWriter в gateway middleware не мешает
+// server-streaming ответам: Flush доходит до соединения, ResponseController видит исходный writer.
+func TestConcurrencyGatewayWriter(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(t *testing.T, w http.ResponseWriter, rec *httptest.ResponseRecorder)
+	}{
+		{"flush", func(t *testing.T, w http.ResponseWriter, rec *httptest.ResponseRecorder) {
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			if !rec.Flushed {
+				t.Error("flush did not reach the underlying writer")
+			}
+		}},
+		{"unwrap", func(t *testing.T, w http.ResponseWriter, rec *httptest.ResponseRecorder) {
+			u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+			if !ok {
+				t.Fatalf("%T has no Unwrap", w)
+			}
+			if u.Unwrap() != rec {
+				t.Errorf("Unwrap() = %T, want the original writer", u.Unwrap())
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newConcurrencyLimiter(ConcurrencyLimitConfig{InitialLimit: 10, MinLimit: 1})
+			rec := httptest.NewRecorder()
+			called := false
+			l.gatewayMiddleware(func(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+				called = true
+				tt.check(t, w, rec)
+			})(rec, httptest.NewRequest(http.MethodGet, "/v1/orders:watch", nil), nil)
+			if !called {
+				t.Fatal("handler not called")
+			}
+		})
+	}
+}
@@ -0,0 +1,330 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const defaultCORSMaxAge = 10 * time.Minute
+
+var (
+	defaultCORSMethods = []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+	}
+	defaultCORSHeaders = []string{
+		"Accept", "Accept-Language", "Content-Language", "Content-Type", "Authorization",
+		"X-Request-ID", "Traceparent", "Tracestate", "Baggage",
+	}
+	defaultCORSExposedHeaders = []string{"X-Request-ID", "X-Trace-ID", "Retry-After", "WWW-Authenticate"}
+)
+
+// CORSConfig — настройки WithCORS для HTTP gateway.
+type CORSConfig struct {
+	// AllowedOrigins — разрешённые origin: точные ("https://app.example.com"),
+	// с одной звёздочкой ("https://*.example.com") или "*" — любой origin.
+	AllowedOrigins []string
+	// AllowOriginFunc — дополнительная проверка origin, вызывается, если AllowedOrigins не подошли.
+	AllowOriginFunc func(origin string) bool
+	// AllowSwaggerOrigin — разрешить origin Swagger UI на SwaggerPort (тот же хост, что у запроса,
+	// или localhost), чтобы «Try it out» работал против gateway.
+	AllowSwaggerOrigin bool
+	// AllowedMethods — методы для preflight. nil — GET, HEAD, POST, PUT, PATCH, DELETE.
+	AllowedMethods []string
+	// AllowedHeaders — заголовки запроса для preflight. nil — стандартные, Authorization,
+	// X-Request-ID и заголовки trace context; "*" — любые.
+	AllowedHeaders []string
+	// ExposedHeaders — заголовки ответа, доступные JS. nil — X-Request-ID, X-Trace-ID, Retry-After, WWW-Authenticate.
+	ExposedHeaders []string
+	// AllowCredentials — разрешить cookies и Authorization из браузера. Несовместимо с origin "*".
+	AllowCredentials bool
+	// MaxAge — сколько браузер кэширует ответ на preflight. 0 — 10m.
+	MaxAge time.Duration
+}
+
+func (c CORSConfig) validate() error {
+	var errs []error
+	if len(c.AllowedOrigins) == 0 && c.AllowOriginFunc == nil && !c.AllowSwaggerOrigin {
+		errs = append(errs, errors.New("no allowed origins"))
+	}
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			if c.AllowCredentials {
+				errs = append(errs, errors.New(`AllowCredentials is not allowed with origin "*"`))
+			}
+			continue
+		}
+		if strings.Count(o, "*") > 1 {
+			errs = append(errs, fmt.Errorf("origin %q: only one * is allowed", o))
+		}
+	}
+	if c.MaxAge < 0 {
+		errs = append(errs, errors.New("MaxAge must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c CORSConfig) allowedMethods() []string {
+	if c.AllowedMethods == nil {
+		return defaultCORSMethods
+	}
+	return c.AllowedMethods
+}
+
+func (c CORSConfig) allowedHeaders() []string {
+	if c.AllowedHeaders == nil {
+		return defaultCORSHeaders
+	}
+	return c.AllowedHeaders
+}
+
+func (c CORSConfig) exposedHeaders() []string {
+	if c.ExposedHeaders == nil {
+		return defaultCORSExposedHeaders
+	}
+	return c.ExposedHeaders
+}
+
+func (c CORSConfig) maxAge() time.Duration {
+	if c.MaxAge <= 0 {
+		return defaultCORSMaxAge
+	}
+	return c.MaxAge
+}
+
+// cors — подготовленная CORS политика HTTP gateway.
+type cors struct {
+	cfg         CORSConfig
+	anyOrigin   bool
+	origins     map[string]struct{} // точные origin в нижнем регистре
+	wildcards   [][2]string         // префикс и суффикс origin со звёздочкой
+	methods     map[string]struct{}
+	anyHeader   bool
+	headers     map[string]struct{} // в нижнем регистре
+	swaggerPort atomic.Pointer[string]
+
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+}
+
+func newCORS(cfg CORSConfig) (*cors, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("cors: %w", err)
+	}
+
+	c := &cors{
+		cfg:           cfg,
+		origins:       make(map[string]struct{}),
+		methods:       make(map[string]struct{}),
+		headers:       make(map[string]struct{}),
+		allowMethods:  strings.Join(cfg.allowedMethods(), ", "),
+		exposeHeaders: strings.Join(cfg.exposedHeaders(), ", "),
+		maxAge:        strconv.Itoa(int(cfg.maxAge().Seconds())),
+	}
+	for _, o := range cfg.AllowedOrigins {
+		o = strings.ToLower(o)
+		switch {
+		case o == "*":
+			c.anyOrigin = true
+		case strings.Contains(o, "*"):
+			prefix, suffix, _ := strings.Cut(o, "*")
+			c.wildcards = append(c.wildcards, [2]string{prefix, suffix})
+		default:
+			c.origins[o] = struct{}{}
+		}
+	}
+	for _, m := range cfg.allowedMethods() {
+		c.methods[strings.ToUpper(m)] = struct{}{}
+	}
+	for _, h := range cfg.allowedHeaders() {
+		if h == "*" {
+			c.anyHeader = true
+			continue
+		}
+		c.headers[strings.ToLower(h)] = struct{}{}
+	}
+	c.allowHeaders = strings.Join(cfg.allowedHeaders(), ", ")
+
+	return c, nil
+}
+
+// setSwaggerAddr запоминает фактический адрес Swagger UI для AllowSwaggerOrigin.
+func (c *cors) setSwaggerAddr(addr string) {
+	if _, port, err := net.SplitHostPort(addr); err == nil {
+		c.swaggerPort.Store(&port)
+	}
+}
+
+// allowOrigin проверяет origin запроса r.
+func (c *cors) allowOrigin(origin string, r *http.Request) bool {
+	if c.anyOrigin {
+		return true
+	}
+	o := strings.ToLower(origin)
+	if _, ok := c.origins[o]; ok {
+		return true
+	}
+	for _, w := range c.wildcards {
+		if len(o) >= len(w[0])+len(w[1]) && strings.HasPrefix(o, w[0]) && strings.HasSuffix(o, w[1]) {
+			return true
+		}
+	}
+	if c.cfg.AllowSwaggerOrigin && c.isSwaggerOrigin(o, r) {
+		return true
+	}
+	return c.cfg.AllowOriginFunc != nil && c.cfg.AllowOriginFunc(origin)
+}
+
+// isSwaggerOrigin сообщает, что origin — Swagger UI этого сервера: http, порт SwaggerPort
+// и тот же хост, по которому браузер обратился к gateway (или loopback).
+func (c *cors) isSwaggerOrigin(origin string, r *http.Request) bool {
+	port := c.swaggerPort.Load()
+	if port == nil {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Scheme != "http" || u.Port() != *port {
+		return false
+	}
+	host := u.Hostname()
+	reqHost := r.Host
+	if h, _, err := net.SplitHostPort(reqHost); err == nil {
+		reqHost = h
+	}
+	return host == strings.ToLower(strings.Trim(reqHost, "[]")) || host == "localhost" || net.ParseIP(host).IsLoopback()
+}
+
+// allowRequestHeaders проверяет заголовки из Access-Control-Request-Headers.
+func (c *cors) allowRequestHeaders(requested string) bool {
+	if c.anyHeader {
+		return true
+	}
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "" {
+			continue
+		}
+		if _, ok := c.headers[h]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// middleware обрабатывает preflight запросы до grpc-gateway mux и добавляет CORS заголовки
+// к остальным ответам. Запросы без Origin и с неразрешённым origin проходят без CORS заголовков —
+// браузер сам заблокирует ответ; неразрешённый preflight получает 403.
+func (c *cors) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		h := w.Header()
+		if !c.anyOrigin || c.cfg.AllowCredentials {
+			h.Add("Vary", "Origin")
+		}
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !c.allowOrigin(origin, r) {
+			if preflight {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c.anyOrigin && !c.cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if c.cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if c.exposeHeaders != "" {
+				h.Set("Access-Control-Expose-Headers", c.exposeHeaders)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+		requested := r.Header.Get("Access-Control-Request-Headers")
+		if _, ok := c.methods[method]; !ok || !c.allowRequestHeaders(requested) {
+			h.Del("Access-Control-Allow-Origin")
+			h.Del("Access-Control-Allow-Credentials")
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		h.Set("Access-Control-Allow-Methods", c.allowMethods)
+		if c.anyHeader {
+			// "*" не работает с credentials — возвращаем запрошенные заголовки
+			if requested != "" {
+				h.Set("Access-Control-Allow-Headers", requested)
+			}
+		} else if c.allowHeaders != "" {
+			h.Set("Access-Control-Allow-Headers", c.allowHeaders)
+		}
+		h.Set("Access-Control-Max-Age", c.maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// initCORS создаёт CORS политику из WithCORS и WithSwaggerCORS. Без опций CORS не включается.
+func (s *Server) initCORS() error {
+	if s.corsCfg == nil && !s.swaggerCORS {
+		return nil
+	}
+
+	cfg := CORSConfig{}
+	if s.corsCfg != nil {
+		cfg = *s.corsCfg
+	}
+	if s.swaggerCORS {
+		cfg.AllowSwaggerOrigin = true
+	}
+
+	c, err := newCORS(cfg)
+	if err != nil {
+		return err
+	}
+	s.cors = c
+	return nil
+}
+
+// swaggerServerURL возвращает URL gateway для «Try it out» Swagger UI на отдельном порту
+// или пустую строку, если запросы из Swagger к gateway не разрешены CORS.
+// {host} заменяется в браузере на хост страницы Swagger.
+func (s *Server) swaggerServerURL() string {
+	if s.cors == nil || !s.cors.cfg.AllowSwaggerOrigin || s.addrs.HTTP == "" {
+		return ""
+	}
+	_, port, err := net.SplitHostPort(s.addrs.HTTP)
+	if err != nil {
+		return ""
+	}
+	scheme := "http"
+	if s.cfg.HTTPTLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://{host}:" + port
+}
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORSConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     CORSConfig
+		wantErr bool
+	}{
+		{"exact origin", CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}, false},
+		{"swagger only", CORSConfig{AllowSwaggerOrigin: true}, false},
+		{"no origins", CORSConfig{}, true},
+		{"any origin with credentials", CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}, true},
+		{"two wildcards", CORSConfig{AllowedOrigins: []string{"https://*.*.example.com"}}, true},
+		{"negative max age", CORSConfig{AllowedOrigins: []string{"*"}, MaxAge: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCORSAllowOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    CORSConfig
+		origin string
+		want   bool
+	}{
+		{"exact", CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}, "https://app.example.com", true},
+		{"exact case insensitive", CORSConfig{AllowedOrigins: []string{"https://App.Example.com"}}, "https://app.EXAMPLE.com", true},
+		{"exact other scheme", CORSConfig{AllowedOrigins: []string{"https://app.example.com"}}, "http://app.example.com", false},
+		{"wildcard subdomain", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "https://a.b.example.com", true},
+		{"wildcard apex", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "https://example.com", false},
+		{"wildcard suffix trick", CORSConfig{AllowedOrigins: []string{"https://*.example.com"}}, "https://example.com.evil.io", false},
+		{"wildcard port", CORSConfig{AllowedOrigins: []string{"http://localhost:*"}}, "http://localhost:3000", true},
+		{"any", CORSConfig{AllowedOrigins: []string{"*"}}, "https://whatever.io", true},
+		{"func", CORSConfig{AllowOriginFunc: func(o string) bool { return strings.HasSuffix(o, ".test") }}, "https://x.test", true},
+		{"func rejects", CORSConfig{AllowOriginFunc: func(string) bool { return false }}, "https://x.test", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newCORS(tt.cfg)
+			if err != nil {
+				t.Fatalf("newCORS: %v", err)
+			}
+			if got := c.allowOrigin(tt.origin, httptest.NewRequest(http.MethodGet, "/", nil)); got != tt.want {
+				t.Errorf("allowOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSSwaggerOrigin(t *testing.T) {
+	c, err := newCORS(CORSConfig{AllowSwaggerOrigin: true})
+	if err != nil {
+		t.Fatalf("newCORS: %v", err)
+	}
+	c.setSwaggerAddr("[::]:8081")
+
+	tests := []struct {
+		name   string
+		host   string
+		origin string
+		want   bool
+	}{
+		{"same host", "api.internal:8080", "http://api.internal:8081", true},
+		{"localhost", "10.0.0.5:8080", "http://localhost:8081", true},
+		{"loopback ip", "10.0.0.5:8080", "http://127.0.0.1:8081", true},
+		{"other host", "api.internal:8080", "http://evil.io:8081", false},
+		{"other port", "api.internal:8080", "http://api.internal:9000", false},
+		{"https", "api.internal:8080", "https://api.internal:8081", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Host = tt.host
+			if got := c.allowOrigin(tt.origin, r); got != tt.want {
+				t.Errorf("allowOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	const origin = "https://app.example.com"
+	exact := CORSConfig{AllowedOrigins: []string{origin}}
+
+	tests := []struct {
+		name   string
+		cfg    CORSConfig
+		method string
+		header map[string]string
+		// wantNext — запрос дошёл до gateway
+		wantNext   bool
+		wantStatus int
+		wantHeader map[string]string
+	}{
+		{
+			name:       "no origin",
+			cfg:        exact,
+			method:     http.MethodGet,
+			wantNext:   true,
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:       "simple request",
+			cfg:        exact,
+			method:     http.MethodGet,
+			header:     map[string]string{"Origin": origin},
+			wantNext:   true,
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{
+				"Access-Control-Allow-Origin":   origin,
+				"Access-Control-Expose-Headers": "X-Request-ID, X-Trace-ID, Retry-After, WWW-Authenticate",
+				"Vary":                          "Origin",
+			},
+		},
+		{
+			name:       "disallowed origin passes without headers",
+			cfg:        exact,
+			method:     http.MethodGet,
+			header:     map[string]string{"Origin": "https://evil.io"},
+			wantNext:   true,
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:       "any origin without credentials",
+			cfg:        CORSConfig{AllowedOrigins: []string{"*"}},
+			method:     http.MethodGet,
+			header:     map[string]string{"Origin": origin},
+			wantNext:   true,
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": "*", "Vary": ""},
+		},
+		{
+			name:       "credentials echo origin",
+			cfg:        CORSConfig{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true},
+			method:     http.MethodGet,
+			header:     map[string]string{"Origin": origin},
+			wantNext:   true,
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{
+				"Access-Control-Allow-Origin":      origin,
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name:   "preflight",
+			cfg:    exact,
+			method: http.MethodOptions,
+			header: map[string]string{
+				"Origin":                         origin,
+				"Access-Control-Request-Method":  "DELETE",
+				"Access-Control-Request-Headers": "authorization, x-request-id",
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeader: map[string]string{
+				"Access-Control-Allow-Origin":  origin,
+				"Access-Control-Allow-Methods": "GET, HEAD, POST, PUT, PATCH, DELETE",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:   "preflight any header echoes requested",
+			cfg:    CORSConfig{AllowedOrigins: []string{origin}, AllowedHeaders: []string{"*"}},
+			method: http.MethodOptions,
+			header: map[string]string{
+				"Origin":                         origin,
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "x-custom",
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeader: map[string]string{"Access-Control-Allow-Headers": "x-custom"},
+		},
+		{
+			name:   "preflight disallowed method",
+			cfg:    exact,
+			method: http.MethodOptions,
+			header: map[string]string{
+				"Origin":                        origin,
+				"Access-Control-Request-Method": "TRACE",
+			},
+			wantStatus: http.StatusForbidden,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "preflight disallowed header",
+			cfg:    exact,
+			method: http.MethodOptions,
+			header: map[string]string{
+				"Origin":                         origin,
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "x-custom",
+			},
+			wantStatus: http.StatusForbidden,
+			wantHeader: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:   "preflight disallowed origin",
+			cfg:    exact,
+			method: http.MethodOptions,
+			header: map[string]string{
+				"Origin":                        "https://evil.io",
+				"Access-Control-Request-Method": "GET",
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "options without request method is not preflight",
+			cfg:        exact,
+			method:     http.MethodOptions,
+			header:     map[string]string{"Origin": origin},
+			wantNext:   true,
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newCORS(tt.cfg)
+			if err != nil {
+				t.Fatalf("newCORS: %v", err)
+			}
+			next := false
+			h := c.middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { next = true }))
+
+			r := httptest.NewRequest(tt.method, "/v1/orders", nil)
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			if next != tt.wantNext {
+				t.Errorf("next called = %v, want %v", next, tt.wantNext)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			for k, v := range tt.wantHeader {
+				if got := rec.Header().Get(k); got != v {
+					t.Errorf("%s = %q, want %q", k, got, v)
+				}
+			}
+		})
+	}
+}
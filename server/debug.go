@@ -2,19 +2,30 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"net/http/pprof"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/vovanwin/platform/logger"
 )
 
-func (s *Server) initDebug(log *slog.Logger) {
+func (s *Server) initDebug(log *slog.Logger) error {
+	var loggerOpts *logger.Options
+	if opts, ok := logger.OptionsOf(log); ok {
+		loggerOpts = &opts
+	}
+	if err := validateDebugConfigs(s.debugConfigs(loggerOpts)); err != nil {
+		return err
+	}
+
 	r := chi.NewRouter()
 
-	// Логирование запросов через slog
-	r.Use(SlogRequestLogger(log))
+	// Логирование запросов через slog, без проб k8s и scrape метрик
+	logOpts := append([]RequestLogOption{RequestLogSkip(debugServerLogSkip...)}, s.requestLogOpts...)
+	r.Use(SlogRequestLogger(log, logOpts...))
 
 	// Пользовательские middleware
 	for _, mw := range s.debugMiddleware {
@@ -28,36 +39,74 @@ func (s *Server) initDebug(log *slog.Logger) {
 	r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	r.HandleFunc("/debug/pprof/trace", pprof.Trace)
 
+	// Уровни логирования во время работы, если логгер создан logger.NewLogger
+	if ctrl, ok := logger.ControllerOf(log); ok {
+		r.Handle("/debug/loglevel", ctrl.Handler())
+	}
+
+	// Итоговая конфигурация модулей и пользовательских структур, секреты скрыты
+	r.HandleFunc("/debug/config", s.configHandler(loggerOpts))
+
+	// Каталог HTTP маршрутов, gRPC методов, middleware и interceptors
+	r.HandleFunc("/debug/routes", s.routesHandler())
+
 	// Пользовательские хэндлеры
 	for _, h := range s.debugHandlers {
 		r.Handle(h.Pattern, h.Handler)
 	}
 
-	// Liveness/readiness для k8s
+	// Liveness для k8s
 	r.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
 
+	// Readiness для k8s: зарегистрированные проверки зависимостей (?verbose — JSON с деталями)
+	r.HandleFunc("/readyz", s.readiness.handler())
+
+	// Startup для k8s: 503, пока не открыты все StartupGate (?verbose — список незавершённых)
+	r.HandleFunc("/startupz", s.startup.handler())
+
+	s.debugRouter = r
+
 	addr := net.JoinHostPort(s.cfg.Host, s.cfg.DebugPort)
 
-	s.debugSrv = &http.Server{
-		Addr:    addr,
-		Handler: r,
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("debug listen %s: %w", addr, err)
+	}
+	s.addrs.Debug = lis.Addr().String()
+
+	s.debugSrv = s.cfg.newHTTPServer(s.addrs.Debug, r)
+	// pprof профили и trace пишутся дольше WriteTimeout (?seconds=30), а тела запросов тут нет
+	s.debugSrv.ReadTimeout = 0
+	s.debugSrv.WriteTimeout = 0
+
+	if s.cfg.DebugTLS != nil {
+		tlsCfg, err := s.cfg.DebugTLS.build(log)
+		if err != nil {
+			_ = lis.Close()
+			return fmt.Errorf("debug tls: %w", err)
+		}
+		s.debugSrv.TLSConfig = tlsCfg
 	}
 
 	go func() {
-		log.Info("Debug сервер запущен", slog.String("addr", addr))
-		if err := s.debugSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Info("Debug сервер запущен", slog.String("addr", s.addrs.Debug), slog.Bool("tls", s.cfg.DebugTLS != nil))
+		if err := serveHTTP(s.debugSrv, lis); err != nil && err != http.ErrServerClosed {
 			log.Error("Debug сервер остановлен с ошибкой", slog.String("error", err.Error()))
 		}
 	}()
+
+	return nil
 }
 
 func (s *Server) stopDebug(ctx context.Context, log *slog.Logger) error {
 	if s.debugSrv != nil {
 		log.Info("Debug сервер завершает работу...")
-		return s.debugSrv.Shutdown(ctx)
+		if err := shutdownHTTPServer(ctx, s.debugSrv); err != nil {
+			return fmt.Errorf("debug shutdown: %w", err)
+		}
 	}
 	return nil
 }
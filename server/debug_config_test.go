@@ -0,0 +1,98 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vovanwin/platform/auth"
+	platformotel "github.com/vovanwin/platform/otel"
+)
+
+func TestDebugConfigRedaction(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	type userConfig struct {
+		DSN      string
+		Password string `secret:"true"`
+		APIKey   string `secret:"true"`
+		Internal string `json:"-"`
+		Level    slog.Level
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		// want — фрагменты, которые должны быть в ответе; hidden — которых быть не должно
+		want   []string
+		hidden []string
+	}{
+		{
+			name:   "secret tag",
+			opts:   []Option{WithDebugConfig("app", userConfig{Password: "p@ss", Level: slog.LevelWarn})},
+			want:   []string{`"Password": "[REDACTED]"`, `"APIKey": ""`, `"Level": "WARN"`},
+			hidden: []string{"p@ss", "Internal"},
+		},
+		{
+			name:   "url password",
+			opts:   []Option{WithDebugConfig("app", &userConfig{DSN: "postgres://user:hunter2@db:5432/app"})},
+			want:   []string{`"DSN": "postgres://user:xxxxx@db:5432/app"`},
+			hidden: []string{"hunter2"},
+		},
+		{
+			name: "debug log tokens and key",
+			opts: []Option{WithDebugLog(platformotel.DebugLogConfig{
+				Tokens: []string{"static-debug-token"},
+				Key:    []byte("debug-hmac-key"),
+			})},
+			want:   []string{`"Tokens": "[REDACTED]"`, `"Key": "[REDACTED]"`},
+			hidden: []string{"static-debug-token", "debug-hmac-key"},
+		},
+		{
+			name: "auth keys",
+			opts: []Option{WithAuth(auth.Config{Keys: map[string]any{
+				"hmac": []byte("jwt-secret"),
+				"ec":   &key.PublicKey,
+			}})},
+			want:   []string{`"Keys": "[REDACTED]"`},
+			hidden: []string{"jwt-secret"},
+		},
+		{
+			name:   "crypto value without tag",
+			opts:   []Option{WithDebugConfig("app", struct{ Signer *ecdsa.PrivateKey }{key})},
+			want:   []string{`"Signer": "<*ecdsa.PrivateKey>"`},
+			hidden: []string{key.D.String()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(Config{}, tt.opts...)
+			rec := httptest.NewRecorder()
+			s.configHandler(nil)(rec, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+
+			body := rec.Body.String()
+			if !json.Valid(rec.Body.Bytes()) {
+				t.Fatalf("invalid JSON: %s", body)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(body, w) {
+					t.Errorf("response does not contain %s:\n%s", w, body)
+				}
+			}
+			for _, h := range tt.hidden {
+				if strings.Contains(body, h) {
+					t.Errorf("response contains %q:\n%s", h, body)
+				}
+			}
+		})
+	}
+}
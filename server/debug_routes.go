@@ -0,0 +1,211 @@
+package server
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	goruntime "runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// routeInfo — HTTP маршрут в ответе /debug/routes.
+type routeInfo struct {
+	// Method — HTTP метод, "*" — любой (chi Handle/HandleFunc/Mount).
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	// Source — "gateway" для маршрутов grpc-gateway, "chi" для роутера.
+	Source string `json:"source"`
+	// GRPCMethod — метод из аннотации google.api.http; пусто для маршрутов WithGatewayHandlePath.
+	GRPCMethod string `json:"grpc_method,omitempty"`
+	// Metrics — для маршрута есть отдельные метрики (WithHTTPRouteMetrics).
+	Metrics bool `json:"metrics"`
+}
+
+// grpcMethodInfo — gRPC метод в ответе /debug/routes.
+type grpcMethodInfo struct {
+	Method          string `json:"method"`
+	ClientStreaming bool   `json:"client_streaming"`
+	ServerStreaming bool   `json:"server_streaming"`
+	// Metrics — для метода есть отдельные метрики (WithGRPCMethodMetrics или автообнаружение при WithOtel).
+	Metrics bool `json:"metrics"`
+}
+
+// routesCatalog — ответ /debug/routes. Middleware и interceptors перечислены от внешнего к внутреннему.
+type routesCatalog struct {
+	HTTP struct {
+		Routes            []routeInfo `json:"routes"`
+		Middleware        []string    `json:"middleware"`
+		GatewayMiddleware []string    `json:"gateway_middleware"`
+		// RouteMetrics — маршруты WithHTTPRouteMetrics.
+		RouteMetrics []string `json:"route_metrics"`
+	} `json:"http"`
+	GRPC struct {
+		Methods            []grpcMethodInfo `json:"methods"`
+		Interceptors       []string         `json:"interceptors"`
+		StreamInterceptors []string         `json:"stream_interceptors"`
+		StatsHandlers      []string         `json:"stats_handlers"`
+	} `json:"grpc"`
+	Debug struct {
+		Routes     []routeInfo `json:"routes"`
+		Middleware []string    `json:"middleware"`
+	} `json:"debug"`
+}
+
+// routesHandler отдаёт /debug/routes: маршруты HTTP gateway и debug сервера, gRPC методы
+// и цепочки middleware и interceptors.
+func (s *Server) routesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		var c routesCatalog
+
+		c.HTTP.Routes = s.gatewayRouteInfos()
+		if s.httpRouter != nil {
+			c.HTTP.Routes = append(c.HTTP.Routes, s.chiRoutes(s.httpRouter)...)
+			c.HTTP.Middleware = funcNames(s.httpRouter.Middlewares())
+		}
+		c.HTTP.GatewayMiddleware = funcNames(s.gatewayMiddlewares())
+		if s.otelCfg != nil {
+			c.HTTP.RouteMetrics = s.httpRoutes
+		}
+
+		c.GRPC.Methods = s.grpcMethodInfos()
+		c.GRPC.Interceptors = s.unaryChain
+		c.GRPC.StreamInterceptors = s.streamChain
+		c.GRPC.StatsHandlers = s.statsChain
+
+		if s.debugRouter != nil {
+			c.Debug.Routes = s.chiRoutes(s.debugRouter)
+			c.Debug.Middleware = funcNames(s.debugRouter.Middlewares())
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(c)
+	}
+}
+
+// gatewayRouteInfos — маршруты ServeMux в том виде, в каком они регистрируются: из аннотаций
+// google.api.http зарегистрированных сервисов (если есть gateway регистраторы) с gRPC методом
+// и WithGatewayHandlePath. Маршруты, добавленные через HandlePath внутри регистратора, не видны.
+func (s *Server) gatewayRouteInfos() []routeInfo {
+	seen := make(map[string]bool)
+	if len(s.gatewayRegistrators)+len(s.gatewayConnRegs) > 0 {
+		for route := range s.gatewayRoutes {
+			seen[route] = true
+		}
+	}
+	for _, p := range s.gatewayPaths {
+		seen[p.method+" "+patternVarRe.ReplaceAllString(p.pattern, "{$1}")] = true
+	}
+
+	routes := make([]routeInfo, 0, len(seen))
+	for route := range seen {
+		m, pattern, _ := strings.Cut(route, " ")
+		routes = append(routes, routeInfo{
+			Method:     m,
+			Pattern:    pattern,
+			Source:     "gateway",
+			GRPCMethod: s.gatewayRoutes[route],
+			Metrics:    s.hasRouteMetrics(route),
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Pattern != routes[j].Pattern {
+			return routes[i].Pattern < routes[j].Pattern
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// chiRoutes обходит роутер chi, сортируя маршруты по шаблону (для Mount — шаблон с "/*").
+// chi.Walk перечисляет маршрут Handle/HandleFunc отдельно для каждого метода — такие
+// маршруты сворачиваются в один с методом "*".
+func (s *Server) chiRoutes(r chi.Routes) []routeInfo {
+	methods := make(map[string][]string)
+	_ = chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		methods[route] = append(methods[route], method)
+		return nil
+	})
+
+	var routes []routeInfo
+	for _, pattern := range slices.Sorted(maps.Keys(methods)) {
+		ms := methods[pattern]
+		if len(ms) == len(allHTTPMethods) {
+			metrics := slices.ContainsFunc(ms, func(m string) bool { return s.hasRouteMetrics(m + " " + pattern) })
+			routes = append(routes, routeInfo{Method: "*", Pattern: pattern, Source: "chi", Metrics: metrics})
+			continue
+		}
+		slices.Sort(ms)
+		for _, m := range ms {
+			routes = append(routes, routeInfo{Method: m, Pattern: pattern, Source: "chi", Metrics: s.hasRouteMetrics(m + " " + pattern)})
+		}
+	}
+	return routes
+}
+
+// allHTTPMethods — методы, на которые chi регистрирует Handle и Mount.
+var allHTTPMethods = []string{
+	http.MethodConnect, http.MethodDelete, http.MethodGet, http.MethodHead, http.MethodOptions,
+	http.MethodPatch, http.MethodPost, http.MethodPut, http.MethodTrace,
+}
+
+func (s *Server) hasRouteMetrics(route string) bool {
+	return s.otelCfg != nil && slices.Contains(s.httpRoutes, route)
+}
+
+// grpcMethodInfos — методы всех зарегистрированных сервисов, включая health и reflection.
+func (s *Server) grpcMethodInfos() []grpcMethodInfo {
+	var methods []grpcMethodInfo
+	if s.grpcServer == nil {
+		return methods
+	}
+	for service, info := range s.grpcServer.GetServiceInfo() {
+		for _, m := range info.Methods {
+			full := "/" + service + "/" + m.Name
+			methods = append(methods, grpcMethodInfo{
+				Method:          full,
+				ClientStreaming: m.IsClientStream,
+				ServerStreaming: m.IsServerStream,
+				Metrics:         s.otelCfg != nil && slices.Contains(s.grpcMethods, full),
+			})
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Method < methods[j].Method })
+	return methods
+}
+
+// closureSuffixRe — суффиксы замыканий и method values в именах функций runtime.
+var closureSuffixRe = regexp.MustCompile(`(\.func\d+|\.\d+|-fm)+$`)
+
+// funcName возвращает короткое имя функции: "server.SlogRequestLogger", "server.(*cors).middleware".
+// Для middleware, созданных конструктором, — имя конструктора.
+func funcName(fn any) string {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	f := goruntime.FuncForPC(v.Pointer())
+	if f == nil {
+		return v.Type().String()
+	}
+	name := f.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return closureSuffixRe.ReplaceAllString(name, "")
+}
+
+func funcNames[T any](fns []T) []string {
+	names := make([]string, 0, len(fns))
+	for _, fn := range fns {
+		names = append(names, funcName(fn))
+	}
+	return names
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/vovanwin/platform/auth"
+	platformotel "github.com/vovanwin/platform/otel"
+	"google.golang.org/grpc"
+)
+
+func TestDebugRoutesChain(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	noop := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(ctx, req)
+	}
+	s := newServer(Config{Host: "127.0.0.1", GRPCPort: "0", HTTPPort: "0"},
+		WithStartupGate(NewStartupGate("cache")),
+		WithDebugLog(platformotel.DebugLogConfig{Tokens: []string{"t"}}),
+		WithConcurrencyLimit(ConcurrencyLimitConfig{}),
+		WithAuth(auth.Config{Keys: map[string]any{"": testAuthKey}}),
+		WithRateLimit(RateLimitConfig{Default: RateLimit{Rate: 10}}),
+		WithGRPCOptions(grpc.ChainUnaryInterceptor(noop)),
+		WithGatewayHandlePath(http.MethodGet, "/v1/files/{name=*}", func(http.ResponseWriter, *http.Request, map[string]string) {}),
+	)
+	s.readiness = newReadiness(nil, s.cfg.readinessTimeout(), s.cfg.readinessCacheTTL())
+	s.initStartup()
+	for _, init := range []func() error{s.initRequestLimits, s.initDebugLog, s.initConcurrencyLimit} {
+		if err := init(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.initAuth(context.Background(), log); err != nil {
+		t.Fatalf("initAuth: %v", err)
+	}
+	if err := s.initRateLimit(); err != nil {
+		t.Fatalf("initRateLimit: %v", err)
+	}
+	if err := s.initGRPC(log); err != nil {
+		t.Fatalf("initGRPC: %v", err)
+	}
+	t.Cleanup(s.grpcServer.Stop)
+	if err := s.initHTTP(log); err != nil {
+		t.Fatalf("initHTTP: %v", err)
+	}
+	t.Cleanup(func() { _ = s.httpServer.Close() })
+
+	rec := httptest.NewRecorder()
+	s.routesHandler()(rec, httptest.NewRequest(http.MethodGet, "/debug/routes", nil))
+	var c routesCatalog
+	if err := json.Unmarshal(rec.Body.Bytes(), &c); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"unary interceptors", c.GRPC.Interceptors, []string{
+			"requestid.UnaryServerInterceptor",
+			"server.SlogUnaryInterceptor",
+			"server.(*startup).unaryInterceptor",
+			"server.(*concurrencyLimiter).unaryInterceptor",
+			"server.(*Server).authUnaryInterceptor",
+			"server.(*rateLimiter).unaryInterceptor",
+			"WithGRPCOptions",
+			"errors.UnaryServerInterceptor",
+		}},
+		{"stream interceptors", c.GRPC.StreamInterceptors, []string{
+			"requestid.StreamServerInterceptor",
+			"server.SlogStreamInterceptor",
+			"server.(*startup).streamInterceptor",
+			"server.(*concurrencyLimiter).streamInterceptor",
+			"server.(*Server).authStreamInterceptor",
+			"server.(*rateLimiter).streamInterceptor",
+			"WithGRPCOptions",
+			"errors.StreamServerInterceptor",
+		}},
+		{"stats handlers", c.GRPC.StatsHandlers, []string{"*otel.debugLogStats"}},
+		{"gateway middleware", c.HTTP.GatewayMiddleware, []string{
+			"server.recordGatewayRoute",
+			"server.(*Server).streamDeadlineMiddleware",
+			"server.(*Server).bodyLimitMiddleware",
+			"server.(*concurrencyLimiter).gatewayMiddleware",
+			"server.gatewayMiddlewareFrom",
+			"server.(*rateLimiter).gatewayMiddleware",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !slices.Equal(tt.got, tt.want) {
+				t.Errorf("got  %q\nwant %q", tt.got, tt.want)
+			}
+		})
+	}
+
+	t.Run("handle path route", func(t *testing.T) {
+		want := routeInfo{Method: http.MethodGet, Pattern: "/v1/files/{name}", Source: "gateway"}
+		if !slices.Contains(c.HTTP.Routes, want) {
+			t.Errorf("routes %+v do not contain %+v", c.HTTP.Routes, want)
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"fmt"
+
+	platformotel "github.com/vovanwin/platform/otel"
+)
+
+// initDebugLog создаёт проверку токенов отладочного логирования, если вызван WithDebugLog.
+// HTTP middleware подключается в initHTTP, gRPC stats handler — в начало опций сервера,
+// раньше otelgrpc, чтобы sampler и propagation видели только проверенный токен.
+func (s *Server) initDebugLog() error {
+	if s.debugLogCfg == nil {
+		return nil
+	}
+
+	d, err := platformotel.NewDebugLog(*s.debugLogCfg)
+	if err != nil {
+		return fmt.Errorf("debug log: %w", err)
+	}
+	s.debugLog = d
+
+	s.prependStatsHandler(d.StatsHandler())
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/vovanwin/platform/logger"
 	platformotel "github.com/vovanwin/platform/otel"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/fx"
@@ -19,56 +20,143 @@ type moduleParams struct {
 	fx.In
 
 	LC                  fx.Lifecycle
+	Shutdowner          fx.Shutdowner
 	Cfg                 Config
 	Log                 *slog.Logger
-	GRPCRegistrators    []GRPCRegistrator    `group:"grpc_registrators"`
-	GatewayRegistrators []GatewayRegistrator `group:"gateway_registrators"`
+	GRPCRegistrators    []GRPCRegistrator        `group:"grpc_registrators"`
+	GatewayRegistrators []GatewayRegistrator     `group:"gateway_registrators"`
+	GatewayConnRegs     []GatewayConnRegistrator `group:"gateway_conn_registrators"`
+	ReadinessChecks     []ReadinessCheck         `group:"readiness_checks"`
+	StartupGates        []*StartupGate           `group:"startup_gates"`
+	ShutdownHooks       []ShutdownHook           `group:"shutdown_hooks"`
+	LogFlush            LogFlush                 `optional:"true"`
+	DebugConfigs        []DebugConfig            `group:"debug_configs"`
 }
 
 // NewModule создаёт fx.Module для серверного пакета.
-// gRPC и gateway регистраторы собираются автоматически через fx groups.
-// Потребитель должен предоставить server.Config и *slog.Logger через fx.Provide.
+// gRPC и gateway регистраторы, readiness-проверки, startup gates, shutdown hooks
+// и конфигурации для /debug/config собираются автоматически через fx groups.
+// Потребитель должен предоставить server.Config и *slog.Logger через fx.Provide;
+// опционально — server.LogFlush для сброса буфера логов последним шагом остановки.
+// Модуль предоставляет *server.Server (напр. для Addrs() в тестах).
 func NewModule(opts ...Option) fx.Option {
 	return fx.Module("server",
-		fx.Invoke(func(p moduleParams) {
+		fx.Provide(func(p moduleParams) *Server {
 			s := newServer(p.Cfg, opts...)
 
 			s.grpcRegistrators = append(s.grpcRegistrators, p.GRPCRegistrators...)
 			s.gatewayRegistrators = append(s.gatewayRegistrators, p.GatewayRegistrators...)
+			s.gatewayConnRegs = append(s.gatewayConnRegs, p.GatewayConnRegs...)
+			s.readinessChecks = append(s.readinessChecks, p.ReadinessChecks...)
+			s.startupGates = append(s.startupGates, p.StartupGates...)
+			s.shutdownHooks = append(s.shutdownHooks, p.ShutdownHooks...)
+			if p.LogFlush != nil {
+				s.logFlush = append(s.logFlush, p.LogFlush)
+			}
+			s.userDebugConfigs = append(s.userDebugConfigs, p.DebugConfigs...)
 
 			p.LC.Append(fx.Hook{
-				OnStart: func(ctx context.Context) error {
+				OnStart: func(ctx context.Context) (err error) {
+					if err := s.cfg.Validate(); err != nil {
+						return err
+					}
+
+					// Если какой-то listener не поднялся, закрываем уже запущенные
+					defer func() {
+						if err != nil {
+							s.abort()
+						}
+					}()
+
 					// Автоматически обнаруживаем gRPC методы до initOtel,
 					// чтобы per-method interceptors попали в grpcOptions до создания сервера.
 					s.discoverGRPCMethods(p.Log)
 
+					s.readiness = newReadiness(s.readinessChecks, s.cfg.readinessTimeout(), s.cfg.readinessCacheTTL())
+					s.initStartup()
+
 					if err := s.initOtel(ctx, p.Log); err != nil {
 						return fmt.Errorf("init otel: %w", err)
 					}
+					if err := s.initRequestLimits(); err != nil {
+						return err
+					}
+					if err := s.initDebugLog(); err != nil {
+						return err
+					}
+					if err := s.initConcurrencyLimit(); err != nil {
+						return err
+					}
+					if err := s.initAuth(ctx, p.Log); err != nil {
+						return err
+					}
+					// Rate limiting — после аутентификации: RateLimitByJWTSubject берёт проверенный "sub"
+					if err := s.initRateLimit(); err != nil {
+						return err
+					}
 					if err := s.initGRPC(p.Log); err != nil {
 						return err
 					}
 					if err := s.initHTTP(p.Log); err != nil {
 						return err
 					}
-					s.initSwagger(p.Log)
-					s.initDebug(p.Log)
+					if err := s.initSwagger(p.Log); err != nil {
+						return err
+					}
+					if err := s.initDebug(p.Log); err != nil {
+						return err
+					}
+					s.startReadinessWatch(p.Log)
+					s.waitStartup(p.Log, p.Shutdowner)
 					s.printBanner()
 					return nil
 				},
 				OnStop: func(ctx context.Context) error {
-					_ = s.stopHTTP(ctx, p.Log)
-					_ = s.stopSwagger(ctx, p.Log)
-					_ = s.stopDebug(ctx, p.Log)
-					s.stopGRPC(p.Log)
-					s.stopOtel(ctx, p.Log)
-					return nil
+					return s.shutdown(ctx, p.Log)
 				},
 			})
+
+			return s
 		}),
+		fx.Invoke(func(*Server) {}),
 	)
 }
 
+// abort немедленно закрывает уже запущенные серверы, если OnStart завершился с ошибкой.
+// fx не вызывает OnStop для хука, чей OnStart упал, поэтому listeners закрываются здесь.
+func (s *Server) abort() {
+	if s.inProcessConn != nil {
+		_ = s.inProcessConn.Close()
+	}
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+	for _, srv := range []*http.Server{s.httpServer, s.swaggerSrv, s.debugSrv} {
+		if srv != nil {
+			_ = srv.Close()
+		}
+	}
+}
+
+// registeredMethods возвращает полные имена методов всех сервисов из GRPCRegistrator.
+// Создаёт временный gRPC сервер только для обнаружения методов — до создания настоящего.
+func (s *Server) registeredMethods() []string {
+	tmpServer := grpc.NewServer()
+	defer tmpServer.Stop()
+
+	for _, reg := range s.grpcRegistrators {
+		reg(tmpServer)
+	}
+
+	var methods []string
+	for serviceName, info := range tmpServer.GetServiceInfo() {
+		for _, method := range info.Methods {
+			methods = append(methods, "/"+serviceName+"/"+method.Name)
+		}
+	}
+	return methods
+}
+
 // discoverGRPCMethods автоматически обнаруживает все gRPC методы из зарегистрированных сервисов.
 // Создаёт временный gRPC сервер, регистрирует все сервисы, извлекает методы через GetServiceInfo(),
 // и добавляет их в s.grpcMethods. Вызывается ДО initOtel, чтобы per-method interceptors
@@ -78,31 +166,20 @@ func (s *Server) discoverGRPCMethods(log *slog.Logger) {
 		return
 	}
 
-	// Создаём временный gRPC сервер только для обнаружения методов
-	tmpServer := grpc.NewServer()
-	for _, reg := range s.grpcRegistrators {
-		reg(tmpServer)
-	}
-
 	seen := make(map[string]struct{})
 	for _, m := range s.grpcMethods {
 		seen[m] = struct{}{}
 	}
 
 	var discovered int
-	for serviceName, info := range tmpServer.GetServiceInfo() {
-		for _, method := range info.Methods {
-			fullMethod := "/" + serviceName + "/" + method.Name
-			if _, ok := seen[fullMethod]; !ok {
-				s.grpcMethods = append(s.grpcMethods, fullMethod)
-				seen[fullMethod] = struct{}{}
-				discovered++
-			}
+	for _, fullMethod := range s.registeredMethods() {
+		if _, ok := seen[fullMethod]; !ok {
+			s.grpcMethods = append(s.grpcMethods, fullMethod)
+			seen[fullMethod] = struct{}{}
+			discovered++
 		}
 	}
 
-	tmpServer.Stop()
-
 	if discovered > 0 {
 		log.Info("gRPC методы обнаружены автоматически",
 			slog.Int("discovered", discovered),
@@ -161,19 +238,32 @@ func (s *Server) initOtel(ctx context.Context, log *slog.Logger) error {
 		s.httpMiddleware = append(s.httpMiddleware, rm.Middleware(chiRouteFunc))
 	}
 
+	// Recovery — в начало gRPC цепочки (после x-request-id), чтобы паника в любом interceptor
+	// или обработчике, в том числе при вызове из gateway, не роняла процесс
+	s.prependInterceptors(serverInterceptors{
+		platformotel.RecoveryUnaryInterceptor(cfg.ServiceName),
+		platformotel.RecoveryStreamInterceptor(cfg.ServiceName),
+	})
+
 	// Добавляем gRPC stats handler для трейсинга + trace_id в response headers
-	s.grpcOptions = append(s.grpcOptions,
-		grpc.StatsHandler(otelgrpc.NewServerHandler()),
-		grpc.ChainUnaryInterceptor(platformotel.TraceIDUnaryInterceptor()),
-	)
+	// и метрики соединений (active, opened, closed, время жизни)
+	s.appendStatsHandlers(otelgrpc.NewServerHandler(), platformotel.NewGRPCConnMetrics(cfg.ServiceName))
+	s.appendInterceptors(serverInterceptors{unary: platformotel.TraceIDUnaryInterceptor()})
 
 	// Per-method gRPC метрики (автоматически обнаруженные + ручные)
 	if len(s.grpcMethods) > 0 {
 		gm := platformotel.NewGRPCMetrics(cfg.ServiceName, s.grpcMethods)
-		s.grpcOptions = append(s.grpcOptions,
-			grpc.ChainUnaryInterceptor(gm.UnaryInterceptor()),
-			grpc.ChainStreamInterceptor(gm.StreamInterceptor()),
-		)
+		s.appendInterceptors(serverInterceptors{gm.UnaryInterceptor(), gm.StreamInterceptor()})
+	}
+
+	// Метрики readiness-проверок (статус и длительность каждой проверки)
+	s.readiness.metrics = platformotel.NewReadinessMetrics(cfg.ServiceName)
+
+	// Текущие уровни логирования, если логгер создан logger.NewLogger
+	if ctrl, ok := logger.ControllerOf(log); ok {
+		if err := platformotel.RegisterLogLevelMetrics(cfg.ServiceName, ctrl.Levels); err != nil {
+			log.Warn("Не удалось зарегистрировать метрику уровней логирования", slog.String("error", err.Error()))
+		}
 	}
 
 	// Монтируем /metrics на debug-сервер
@@ -190,44 +280,120 @@ func (s *Server) initOtel(ctx context.Context, log *slog.Logger) error {
 	return nil
 }
 
-func (s *Server) stopOtel(ctx context.Context, log *slog.Logger) {
+// initStartup включает режим запуска, если зарегистрированы StartupGate:
+// /readyz отвечает 503, gRPC вызовы (кроме health и reflection) получают UNAVAILABLE.
+func (s *Server) initStartup() {
+	s.startup = newStartup(s.startupGates)
+	if s.startup.ready.Load() {
+		return
+	}
+
+	s.readiness.block("starting")
+
+	// Startup gating — первым в цепочке, до пользовательских и OTEL interceptors
+	s.prependInterceptors(serverInterceptors{s.startup.unaryInterceptor(), s.startup.streamInterceptor()})
+}
+
+// waitStartup ждёт открытия всех StartupGate в фоне. Если они не открылись за
+// Config.StartupTimeout, fx приложение останавливается с ненулевым кодом выхода.
+func (s *Server) waitStartup(log *slog.Logger, shutdowner fx.Shutdowner) {
+	s.startup.wait(log, s.cfg.StartupTimeout,
+		func() {
+			s.readiness.unblock()
+		},
+		func(pending []string) {
+			log.Error("Запуск не завершён за StartupTimeout, приложение останавливается",
+				slog.Duration("timeout", s.cfg.StartupTimeout),
+				slog.Any("pending", pending),
+			)
+			if err := shutdowner.Shutdown(fx.ExitCode(1)); err != nil {
+				log.Error("Не удалось остановить приложение", slog.String("error", err.Error()))
+			}
+		},
+	)
+}
+
+// stopHealth останавливает фоновые readiness-проверки и переводит /readyz и gRPC health
+// в NOT_SERVING, чтобы клиенты и балансировщики перестали слать новые запросы до остановки серверов.
+func (s *Server) stopHealth() {
+	if s.stopReadinessWatch != nil {
+		s.stopReadinessWatch()
+	}
+	if s.readiness != nil {
+		s.readiness.block("shutting down")
+	}
+	if s.health != nil {
+		s.health.Shutdown()
+	}
+}
+
+func (s *Server) stopOtel(ctx context.Context, log *slog.Logger) error {
 	if s.otelProvider != nil {
 		log.Info("OTEL провайдеры завершают работу...")
 		if err := s.otelProvider.Shutdown(ctx); err != nil {
-			log.Error("Ошибка при завершении OTEL", slog.String("error", err.Error()))
+			return fmt.Errorf("otel shutdown: %w", err)
 		}
 	}
+	return nil
 }
 
 func (s *Server) printBanner() {
 	host := s.cfg.Host
-	if host == "" || host == "0.0.0.0" {
+	if host == "" || host == "0.0.0.0" || host == "::" {
 		host = "localhost"
 	}
 
-	httpAddr := net.JoinHostPort(host, s.cfg.HTTPPort)
-	grpcAddr := net.JoinHostPort(host, s.cfg.GRPCPort)
-	swaggerAddr := net.JoinHostPort(host, s.cfg.SwaggerPort)
-	debugAddr := net.JoinHostPort(host, s.cfg.DebugPort)
+	// Порты берём из фактических адресов — при порте "0" их выбирает ОС
+	displayAddr := func(addr string) string {
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return addr
+		}
+		return net.JoinHostPort(host, port)
+	}
+
+	httpAddr := displayAddr(s.addrs.HTTP)
+	grpcAddr := displayAddr(s.addrs.GRPC)
+	debugAddr := displayAddr(s.addrs.Debug)
+
+	httpScheme, debugScheme, grpcMode := "http", "http", "plaintext"
+	if s.cfg.HTTPTLS != nil {
+		httpScheme = "https"
+	}
+	if s.cfg.DebugTLS != nil {
+		debugScheme = "https"
+	}
+	if (s.cfg.SinglePort && s.cfg.HTTPTLS != nil) || (!s.cfg.SinglePort && s.cfg.GRPCTLS != nil) {
+		grpcMode = "tls"
+	}
+	if s.cfg.SinglePort {
+		grpcMode += ", single-port"
+	}
 
 	fmt.Println()
 	fmt.Println("  ┌──────────────────────────────────────────────┐")
 	fmt.Println("  │              Сервер запущен                   │")
 	fmt.Println("  ├──────────────────────────────────────────────┤")
-	fmt.Printf("  │  HTTP:     http://%s\n", httpAddr)
-	fmt.Printf("  │  gRPC:     %s\n", grpcAddr)
-	fmt.Printf("  │  Swagger:  http://%s\n", swaggerAddr)
-	fmt.Printf("  │  Debug:    http://%s/debug/pprof/\n", debugAddr)
-	fmt.Printf("  │  Health:   http://%s/healthz\n", debugAddr)
+	fmt.Printf("  │  HTTP:     %s://%s\n", httpScheme, httpAddr)
+	fmt.Printf("  │  gRPC:     %s (%s)\n", grpcAddr, grpcMode)
+	if s.cfg.SinglePort && s.cfg.SinglePortSwagger && s.addrs.Swagger != "" {
+		fmt.Printf("  │  Swagger:  %s://%s/swagger/\n", httpScheme, httpAddr)
+	} else if s.addrs.Swagger != "" {
+		fmt.Printf("  │  Swagger:  http://%s\n", displayAddr(s.addrs.Swagger))
+	}
+	fmt.Printf("  │  Debug:    %s://%s/debug/pprof/\n", debugScheme, debugAddr)
+	fmt.Printf("  │  Health:   %s://%s/healthz\n", debugScheme, debugAddr)
+	fmt.Printf("  │  Ready:    %s://%s/readyz\n", debugScheme, debugAddr)
+	fmt.Printf("  │  Startup:  %s://%s/startupz\n", debugScheme, debugAddr)
 	if s.otelCfg != nil {
-		fmt.Printf("  │  Metrics:  http://%s/metrics\n", debugAddr)
+		fmt.Printf("  │  Metrics:  %s://%s/metrics\n", debugScheme, debugAddr)
 	}
 	if len(s.debugHandlers) > 0 {
 		for _, h := range s.debugHandlers {
 			if h.Pattern == "/metrics" && s.otelCfg != nil {
 				continue // уже вывели выше
 			}
-			fmt.Printf("  │  Custom:   http://%s%s\n", debugAddr, h.Pattern)
+			fmt.Printf("  │  Custom:   %s://%s%s\n", debugScheme, debugAddr, h.Pattern)
 		}
 	}
 	fmt.Println("  └──────────────────────────────────────────────┘")
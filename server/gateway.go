@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	platformerrors "github.com/vovanwin/platform/errors"
+)
+
+// GatewayHeaderMatcher — платформенный matcher входящих заголовков grpc-gateway.
+// Заголовки корреляции и трейсинга (X-Request-ID, traceparent, tracestate, baggage) передаются
+// в gRPC метаданные без префикса, остальные — по правилам runtime.DefaultHeaderMatcher.
+// Authorization gateway передаёт как "authorization" сам, отдельно от matcher.
+func GatewayHeaderMatcher(key string) (string, bool) {
+	switch textproto.CanonicalMIMEHeaderKey(key) {
+	case "X-Request-Id", "Traceparent", "Tracestate", "Baggage":
+		return strings.ToLower(key), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// patternVarRe находит переменные пути вида {id=*} — они приводятся к {id}, как в proto аннотации.
+var patternVarRe = regexp.MustCompile(`\{([^=}]+)=\*\}`)
+
+// gatewayRoute возвращает маршрут запроса grpc-gateway в виде "METHOD /pattern" (напр. "GET /v1/users/{id}").
+// Шаблон доступен только внутри gateway middleware; вне его возвращается "METHOD /path".
+func gatewayRoute(r *http.Request) string {
+	if p, ok := gatewayPattern(r); ok {
+		return r.Method + " " + p
+	}
+	return r.Method + " " + r.URL.Path
+}
+
+// gatewayPattern возвращает шаблон пути grpc-gateway (напр. "/v1/users/{id}").
+func gatewayPattern(r *http.Request) (string, bool) {
+	p, ok := runtime.HTTPPattern(r.Context())
+	if !ok {
+		return "", false
+	}
+	return patternVarRe.ReplaceAllString(p.String(), "{$1}"), true
+}
+
+// routeHolder передаёт шаблон маршрута из gateway middleware обратно в SlogRequestLogger:
+// runtime.HTTPPattern доступен только в контексте внутри grpc-gateway.
+type routeHolder struct {
+	pattern string
+}
+
+type routeHolderKey struct{}
+
+func withRouteHolder(ctx context.Context) (context.Context, *routeHolder) {
+	h := &routeHolder{}
+	return context.WithValue(ctx, routeHolderKey{}, h), h
+}
+
+// recordGatewayRoute — gateway middleware, сохраняющий шаблон маршрута для лога запроса.
+func recordGatewayRoute(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		if h, ok := r.Context().Value(routeHolderKey{}).(*routeHolder); ok {
+			h.pattern, _ = gatewayPattern(r)
+		}
+		next(w, r, pathParams)
+	}
+}
+
+// gatewayMuxOptions возвращает опции grpc-gateway ServeMux: сначала платформенные по умолчанию,
+// затем пользовательские из WithGatewayMuxOptions (для опций-одиночек побеждает последняя).
+func (s *Server) gatewayMuxOptions() []runtime.ServeMuxOption {
+	opts := []runtime.ServeMuxOption{
+		runtime.WithIncomingHeaderMatcher(GatewayHeaderMatcher),
+		runtime.WithErrorHandler(platformerrors.GatewayErrorHandler),
+		runtime.WithMiddlewares(s.gatewayMiddlewares()...),
+	}
+	return append(opts, s.gatewayMuxOpts...)
+}
+
+// gatewayMiddlewares — цепочка middleware ServeMux; по ней же строится список в /debug/routes.
+func (s *Server) gatewayMiddlewares() []runtime.Middleware {
+	return append([]runtime.Middleware{recordGatewayRoute}, s.gatewayMiddleware...)
+}
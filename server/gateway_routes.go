@@ -0,0 +1,82 @@
+package server
+
+import (
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// gatewayRouteMethods сопоставляет маршруты grpc-gateway ("METHOD /pattern", как gatewayRoute)
+// полным gRPC методам по аннотациям google.api.http в proto дескрипторах зарегистрированных сервисов.
+// Нужен там, где REST вызывает сервисы напрямую (WithGatewayRegistrator) в обход gRPC interceptors.
+func gatewayRouteMethods(services map[string]grpc.ServiceInfo) map[string]string {
+	routes := make(map[string]string)
+	for serviceName := range services {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(serviceName))
+		if err != nil {
+			continue
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			md := methods.Get(i)
+			rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				continue
+			}
+
+			fullMethod := "/" + serviceName + "/" + string(md.Name())
+			for _, b := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+				if method, path := httpRuleRoute(b); path != "" {
+					routes[method+" "+patternVarRe.ReplaceAllString(path, "{$1}")] = fullMethod
+				}
+			}
+		}
+	}
+	return routes
+}
+
+// gatewayStreamRoutes отбирает маршруты gateway, за которыми стоят streaming gRPC методы.
+func gatewayStreamRoutes(routes map[string]string, services map[string]grpc.ServiceInfo) map[string]struct{} {
+	streaming := make(map[string]struct{})
+	for service, info := range services {
+		for _, m := range info.Methods {
+			if m.IsClientStream || m.IsServerStream {
+				streaming["/"+service+"/"+m.Name] = struct{}{}
+			}
+		}
+	}
+	streams := make(map[string]struct{})
+	for route, method := range routes {
+		if _, ok := streaming[method]; ok {
+			streams[route] = struct{}{}
+		}
+	}
+	return streams
+}
+
+// httpRuleRoute возвращает HTTP метод и шаблон пути из google.api.http правила.
+func httpRuleRoute(rule *annotations.HttpRule) (string, string) {
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return "GET", p.Get
+	case *annotations.HttpRule_Put:
+		return "PUT", p.Put
+	case *annotations.HttpRule_Post:
+		return "POST", p.Post
+	case *annotations.HttpRule_Delete:
+		return "DELETE", p.Delete
+	case *annotations.HttpRule_Patch:
+		return "PATCH", p.Patch
+	case *annotations.HttpRule_Custom:
+		return p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return "", ""
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+// TestGatewayHeaderMatcher проверяет, какие заголовки REST запроса попадают в gRPC метаданные
+// через ServeMux с платформенными опциями.
+func TestGatewayHeaderMatcher(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		header string
+		value  string
+		// wantKey — ключ в метаданных; пусто — заголовок не передаётся
+		wantKey string
+	}{
+		{name: "request id", header: "X-Request-ID", value: "req-1", wantKey: "x-request-id"},
+		{name: "traceparent", header: "traceparent", value: "00-abc-def-01", wantKey: "traceparent"},
+		{name: "tracestate", header: "Tracestate", value: "k=v", wantKey: "tracestate"},
+		{name: "baggage", header: "Baggage", value: "user=1", wantKey: "baggage"},
+		{name: "grpc metadata prefix", header: "Grpc-Metadata-Tenant", value: "acme", wantKey: "tenant"},
+		{name: "permanent header", header: "Accept-Language", value: "ru", wantKey: "grpcgateway-accept-language"},
+		{name: "custom header dropped", header: "X-Internal-Secret", value: "s"},
+		{
+			name: "user matcher replaces default",
+			opts: []Option{WithGatewayMuxOptions(runtime.WithIncomingHeaderMatcher(func(key string) (string, bool) {
+				return "custom-" + strings.ToLower(key), true
+			}))},
+			header:  "X-Request-ID",
+			value:   "req-1",
+			wantKey: "custom-x-request-id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := runtime.NewServeMux(newServer(Config{}, tt.opts...).gatewayMuxOptions()...)
+			r := httptest.NewRequest(http.MethodGet, "/v1/orders", nil)
+			r.Header.Set(tt.header, tt.value)
+
+			ctx, err := runtime.AnnotateIncomingContext(context.Background(), mux, r, "/orders.OrderService/List")
+			if err != nil {
+				t.Fatalf("AnnotateIncomingContext: %v", err)
+			}
+			md, _ := metadata.FromIncomingContext(ctx)
+
+			if tt.wantKey == "" {
+				for key, values := range md {
+					if slices.Contains(values, tt.value) {
+						t.Errorf("header %s passed as %q", tt.header, key)
+					}
+				}
+				return
+			}
+			if got := md.Get(tt.wantKey); !slices.Equal(got, []string{tt.value}) {
+				t.Errorf("metadata %q = %v, want [%s] (all: %v)", tt.wantKey, got, tt.value, md)
+			}
+		})
+	}
+}
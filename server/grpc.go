@@ -1,35 +1,80 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net"
 
+	platformerrors "github.com/vovanwin/platform/errors"
+	"github.com/vovanwin/platform/requestid"
 	"github.com/vovanwin/platform/server/grpc/health"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/stats"
 )
 
 func (s *Server) initGRPC(log *slog.Logger) error {
+	if s.cfg.GRPCTLS != nil && !s.cfg.SinglePort {
+		tlsCfg, err := s.cfg.GRPCTLS.build(log)
+		if err != nil {
+			return fmt.Errorf("grpc tls: %w", err)
+		}
+		// In-process соединение gateway (WithGatewayConnRegistrator) обслуживается тем же сервером без TLS
+		s.grpcOptions = append(s.grpcOptions, grpc.Creds(inProcessCreds{credentials.NewTLS(tlsCfg)}))
+	}
+
+	// Keepalive и лимиты соединений — в начало, WithGRPCOptions может их переопределить
+	if !s.cfg.SinglePort {
+		s.grpcOptions = append(s.cfg.GRPCKeepalive.serverOptions(), s.grpcOptions...)
+	}
+
+	// x-request-id — первым в цепочке, чтобы его видели все interceptors и обработчики
+	// Access log — сразу после него: видит итоговый код ответа и полную длительность вызова
+	s.prependInterceptors(
+		serverInterceptors{requestid.UnaryServerInterceptor(), requestid.StreamServerInterceptor()},
+		serverInterceptors{SlogUnaryInterceptor(log, s.grpcLogOpts...), SlogStreamInterceptor(log, s.grpcLogOpts...)},
+	)
+
+	// Пользовательские опции — после платформенных: их interceptors видят Claims аутентификации.
+	// Опции непрозрачны, поэтому в /debug/routes они — одно звено WithGRPCOptions
+	if len(s.userGRPCOptions) > 0 {
+		s.grpcOptions = append(s.grpcOptions, s.userGRPCOptions...)
+		s.unaryChain = append(s.unaryChain, "WithGRPCOptions")
+		s.streamChain = append(s.streamChain, "WithGRPCOptions")
+	}
+
+	// Перевод доменных ошибок в gRPC статусы — последним в цепочке,
+	// чтобы метрики и пользовательские interceptors видели итоговый код
+	s.appendInterceptors(serverInterceptors{platformerrors.UnaryServerInterceptor(), platformerrors.StreamServerInterceptor()})
+
 	s.grpcServer = grpc.NewServer(s.grpcOptions...)
 
 	for _, reg := range s.grpcRegistrators {
 		reg(s.grpcServer)
 	}
 
-	health.RegisterService(s.grpcServer)
+	// Health регистрируется последним: все сервисы выше получают статус SERVING
+	s.health = health.RegisterService(s.grpcServer)
 	reflection.Register(s.grpcServer)
 
+	// В single-port режиме gRPC обслуживается HTTP gateway listener-ом (см. initHTTP)
+	if s.cfg.SinglePort {
+		return nil
+	}
+
 	addr := net.JoinHostPort(s.cfg.Host, s.cfg.GRPCPort)
 
 	lis, err := net.Listen("tcp", addr)
 	if err != nil {
-		return fmt.Errorf("grpc listen: %w", err)
+		return fmt.Errorf("grpc listen %s: %w", addr, err)
 	}
+	s.addrs.GRPC = lis.Addr().String()
 
 	go func() {
-		log.Info("gRPC сервер запущен", slog.String("addr", addr))
+		log.Info("gRPC сервер запущен", slog.String("addr", s.addrs.GRPC))
 		if err := s.grpcServer.Serve(lis); err != nil {
 			log.Error("gRPC сервер остановлен с ошибкой", slog.String("error", err.Error()))
 		}
@@ -38,9 +83,80 @@ func (s *Server) initGRPC(log *slog.Logger) error {
 	return nil
 }
 
-func (s *Server) stopGRPC(log *slog.Logger) {
-	if s.grpcServer != nil {
-		log.Info("gRPC сервер завершает работу...")
+// stopGRPC ждёт завершения активных RPC через GracefulStop, а после дедлайна ctx
+// обрывает оставшиеся стримы через Stop.
+func (s *Server) stopGRPC(ctx context.Context, log *slog.Logger) error {
+	if s.grpcServer == nil {
+		return nil
+	}
+
+	log.Info("gRPC сервер завершает работу...")
+
+	done := make(chan struct{})
+	go func() {
 		s.grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		log.Warn("gRPC сервер не завершился до дедлайна, принудительная остановка")
+		s.grpcServer.Stop()
+		<-done
+		return fmt.Errorf("grpc graceful stop: %w", ctx.Err())
 	}
 }
+
+// serverInterceptors — unary и stream interceptor одного звена цепочки; nil — звено
+// не перехватывает этот тип вызовов.
+type serverInterceptors struct {
+	unary  grpc.UnaryServerInterceptor
+	stream grpc.StreamServerInterceptor
+}
+
+// appendInterceptors добавляет звенья в конец платформенной цепочки и запоминает их имена
+// для /debug/routes: grpc.ServerOption непрозрачны, восстановить цепочку по ним нельзя.
+func (s *Server) appendInterceptors(links ...serverInterceptors) {
+	opts, unary, stream := interceptorOptions(links)
+	s.grpcOptions = append(s.grpcOptions, opts...)
+	s.unaryChain = append(s.unaryChain, unary...)
+	s.streamChain = append(s.streamChain, stream...)
+}
+
+// prependInterceptors добавляет звенья в начало цепочки, сохраняя их порядок.
+func (s *Server) prependInterceptors(links ...serverInterceptors) {
+	opts, unary, stream := interceptorOptions(links)
+	s.grpcOptions = append(opts, s.grpcOptions...)
+	s.unaryChain = append(unary, s.unaryChain...)
+	s.streamChain = append(stream, s.streamChain...)
+}
+
+func interceptorOptions(links []serverInterceptors) (opts []grpc.ServerOption, unary, stream []string) {
+	for _, l := range links {
+		if l.unary != nil {
+			opts = append(opts, grpc.ChainUnaryInterceptor(l.unary))
+			unary = append(unary, funcName(l.unary))
+		}
+		if l.stream != nil {
+			opts = append(opts, grpc.ChainStreamInterceptor(l.stream))
+			stream = append(stream, funcName(l.stream))
+		}
+	}
+	return opts, unary, stream
+}
+
+// appendStatsHandlers добавляет stats handlers в конец списка и запоминает их типы для /debug/routes.
+func (s *Server) appendStatsHandlers(handlers ...stats.Handler) {
+	for _, h := range handlers {
+		s.grpcOptions = append(s.grpcOptions, grpc.StatsHandler(h))
+		s.statsChain = append(s.statsChain, fmt.Sprintf("%T", h))
+	}
+}
+
+// prependStatsHandler добавляет stats handler в начало списка: он вызывается раньше остальных.
+func (s *Server) prependStatsHandler(h stats.Handler) {
+	s.grpcOptions = append([]grpc.ServerOption{grpc.StatsHandler(h)}, s.grpcOptions...)
+	s.statsChain = append([]string{fmt.Sprintf("%T", h)}, s.statsChain...)
+}
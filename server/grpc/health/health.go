@@ -2,31 +2,167 @@ package health
 
 import (
 	"context"
+	"sync"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
-// Server implements the gRPC Health Checking Protocol (GRPC Health v1)
+// Server implements the gRPC Health Checking Protocol (GRPC Health v1).
+// It tracks a serving status per service ("" is the overall server status)
+// and streams status transitions to Watch subscribers.
 type Server struct {
 	grpc_health_v1.UnimplementedHealthServer
+
+	mu       sync.RWMutex
+	shutdown bool
+	statuses map[string]grpc_health_v1.HealthCheckResponse_ServingStatus
+	watchers map[string]map[chan grpc_health_v1.HealthCheckResponse_ServingStatus]struct{}
+}
+
+// NewServer creates a health server with the overall status set to SERVING.
+func NewServer() *Server {
+	return &Server{
+		statuses: map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{
+			"": grpc_health_v1.HealthCheckResponse_SERVING,
+		},
+		watchers: make(map[string]map[chan grpc_health_v1.HealthCheckResponse_ServingStatus]struct{}),
+	}
 }
 
-// Check implements the standard grpc health check protocol
+// Check implements the standard grpc health check protocol.
+// Unknown services are reported with codes.NotFound.
 func (s *Server) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
-	return &grpc_health_v1.HealthCheckResponse{
-		Status: grpc_health_v1.HealthCheckResponse_SERVING,
-	}, nil
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st, ok := s.statuses[req.GetService()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: st}, nil
 }
 
-// Watch implements the standard grpc health check protocol
+// Watch implements the standard grpc health check protocol.
+// It sends the current status immediately and then every transition
+// until the client disconnects.
 func (s *Server) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
-	return stream.Send(&grpc_health_v1.HealthCheckResponse{
-		Status: grpc_health_v1.HealthCheckResponse_SERVING,
-	})
+	service := req.GetService()
+
+	// Buffer of one: a slow subscriber only ever sees the latest status.
+	update := make(chan grpc_health_v1.HealthCheckResponse_ServingStatus, 1)
+
+	s.mu.Lock()
+	if st, ok := s.statuses[service]; ok {
+		update <- st
+	} else {
+		update <- grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	if s.watchers[service] == nil {
+		s.watchers[service] = make(map[chan grpc_health_v1.HealthCheckResponse_ServingStatus]struct{})
+	}
+	s.watchers[service][update] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.watchers[service], update)
+		if len(s.watchers[service]) == 0 {
+			delete(s.watchers, service)
+		}
+		s.mu.Unlock()
+	}()
+
+	var last grpc_health_v1.HealthCheckResponse_ServingStatus = -1
+	for {
+		select {
+		case st := <-update:
+			if st == last {
+				continue
+			}
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: st}); err != nil {
+				return status.Error(codes.Canceled, "stream has ended")
+			}
+			last = st
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		}
+	}
+}
+
+// SetServingStatus sets the status of a service and notifies watchers.
+// Calls after Shutdown are ignored until Resume.
+func (s *Server) SetServingStatus(service string, st grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shutdown {
+		return
+	}
+	s.setLocked(service, st)
+}
+
+// SetAllServingStatus sets the same status for every known service, including the overall one.
+// Calls after Shutdown are ignored until Resume.
+func (s *Server) SetAllServingStatus(st grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shutdown {
+		return
+	}
+	for service := range s.statuses {
+		s.setLocked(service, st)
+	}
+}
+
+// Shutdown marks every service as NOT_SERVING and ignores further status updates.
+// It is called when the server begins graceful shutdown.
+func (s *Server) Shutdown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shutdown = true
+	for service := range s.statuses {
+		s.setLocked(service, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	}
+}
+
+// Resume marks every service as SERVING and accepts status updates again.
+func (s *Server) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shutdown = false
+	for service := range s.statuses {
+		s.setLocked(service, grpc_health_v1.HealthCheckResponse_SERVING)
+	}
+}
+
+func (s *Server) setLocked(service string, st grpc_health_v1.HealthCheckResponse_ServingStatus) {
+	s.statuses[service] = st
+	for ch := range s.watchers[service] {
+		// Drop a stale pending status so the watcher receives the latest one.
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- st
+	}
 }
 
-// RegisterService registers the health service with the gRPC server
-func RegisterService(s *grpc.Server) {
-	grpc_health_v1.RegisterHealthServer(s, &Server{})
+// RegisterService registers the health service with the gRPC server and marks
+// every service already registered on it as SERVING.
+// It must be called after all application services are registered.
+func RegisterService(s *grpc.Server) *Server {
+	hs := NewServer()
+	grpc_health_v1.RegisterHealthServer(s, hs)
+
+	for service := range s.GetServiceInfo() {
+		hs.SetServingStatus(service, grpc_health_v1.HealthCheckResponse_SERVING)
+	}
+
+	return hs
 }
@@ -0,0 +1,145 @@
+package health
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startServer serves a health server over bufconn and returns it with a connected client.
+func startServer(t *testing.T) (*Server, grpc_health_v1.HealthClient) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	hs := RegisterService(gs)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return hs, grpc_health_v1.NewHealthClient(conn)
+}
+
+func TestWatch(t *testing.T) {
+	const (
+		serving    = grpc_health_v1.HealthCheckResponse_SERVING
+		notServing = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		unknown    = grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+	)
+
+	tests := []struct {
+		name    string
+		service string
+		change  func(s *Server)
+		// initial is sent right after Watch, want are the transitions after change
+		initial grpc_health_v1.HealthCheckResponse_ServingStatus
+		want    []grpc_health_v1.HealthCheckResponse_ServingStatus
+	}{
+		{
+			name:    "failed readiness check",
+			change:  func(s *Server) { s.SetAllServingStatus(notServing) },
+			initial: serving,
+			want:    []grpc_health_v1.HealthCheckResponse_ServingStatus{notServing},
+		},
+		{
+			name: "shutdown ignores later updates",
+			change: func(s *Server) {
+				s.Shutdown()
+				s.SetAllServingStatus(serving)
+				s.SetServingStatus("", serving)
+			},
+			initial: serving,
+			want:    []grpc_health_v1.HealthCheckResponse_ServingStatus{notServing},
+		},
+		{
+			name:    "unknown service becomes known",
+			service: "orders.OrderService",
+			change:  func(s *Server) { s.SetServingStatus("orders.OrderService", serving) },
+			initial: unknown,
+			want:    []grpc_health_v1.HealthCheckResponse_ServingStatus{serving},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs, client := startServer(t)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: tt.service})
+			if err != nil {
+				t.Fatalf("Watch: %v", err)
+			}
+			resp, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("first Recv: %v", err)
+			}
+			if resp.GetStatus() != tt.initial {
+				t.Fatalf("initial status = %v, want %v", resp.GetStatus(), tt.initial)
+			}
+
+			tt.change(hs)
+			for i, want := range tt.want {
+				resp, err := stream.Recv()
+				if err != nil {
+					t.Fatalf("Recv %d: %v", i, err)
+				}
+				if resp.GetStatus() != want {
+					t.Errorf("status %d = %v, want %v", i, resp.GetStatus(), want)
+				}
+			}
+
+			last := tt.want[len(tt.want)-1]
+			resp, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: tt.service})
+			if err != nil || resp.GetStatus() != last {
+				t.Errorf("Check = %v, %v, want %v", resp.GetStatus(), err, last)
+			}
+		})
+	}
+}
+
+func TestWatchClientCancel(t *testing.T) {
+	hs, client := startServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("first Recv: %v", err)
+	}
+
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("Recv after cancel: %v, want Canceled", err)
+	}
+
+	// The server side of the stream ends and unsubscribes its watcher.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		hs.mu.RLock()
+		n := len(hs.watchers)
+		hs.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d watchers left after client cancel", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/vovanwin/platform/requestid"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// defaultGRPCLogSkip — служебные методы, которые вызываются часто и не нужны в access log.
+var defaultGRPCLogSkip = []string{"/grpc.health.v1.Health/*", "/grpc.reflection.*"}
+
+// GRPCLogOption настраивает SlogUnaryInterceptor и SlogStreamInterceptor.
+type GRPCLogOption func(*grpcLogConfig)
+
+type grpcLogConfig struct {
+	skip      []string
+	level     func(codes.Code) slog.Level
+	inProcess bool
+}
+
+// GRPCLogSkip не логирует методы: полное имя ("/pkg.Service/Method") или префикс со звёздочкой
+// ("/pkg.Service/*"). Health и reflection пропускаются всегда.
+func GRPCLogSkip(methods ...string) GRPCLogOption {
+	return func(c *grpcLogConfig) {
+		c.skip = append(c.skip, methods...)
+	}
+}
+
+// GRPCLogLevel задаёт уровень записи по коду ответа вместо GRPCCodeLevel.
+func GRPCLogLevel(level func(codes.Code) slog.Level) GRPCLogOption {
+	return func(c *grpcLogConfig) {
+		c.level = level
+	}
+}
+
+// GRPCLogInProcess логирует и вызовы grpc-gateway через in-process соединение.
+// По умолчанию они пропускаются: REST запрос уже записан SlogRequestLogger.
+func GRPCLogInProcess() GRPCLogOption {
+	return func(c *grpcLogConfig) {
+		c.inProcess = true
+	}
+}
+
+func newGRPCLogConfig(opts []GRPCLogOption) *grpcLogConfig {
+	c := &grpcLogConfig{skip: slices.Clone(defaultGRPCLogSkip), level: GRPCCodeLevel}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *grpcLogConfig) skipped(ctx context.Context, method string) bool {
+	if !c.inProcess && isInProcess(ctx) {
+		return true
+	}
+	for _, pattern := range c.skip {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(method, prefix) {
+				return true
+			}
+		} else if method == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// GRPCCodeLevel — уровень записи по умолчанию: Info для OK, Warn для ошибок клиента
+// (InvalidArgument, NotFound, PermissionDenied и т.п.), Error для ошибок сервера
+// (Internal, Unavailable, DeadlineExceeded, Unknown, Unimplemented, DataLoss).
+func GRPCCodeLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK:
+		return slog.LevelInfo
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// log пишет одну запись access log для завершённого вызова.
+func (c *grpcLogConfig) log(ctx context.Context, log *slog.Logger, msg, method string, start time.Time, err error, extra ...slog.Attr) {
+	code := status.Code(err)
+	level := c.level(code)
+	if !log.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 8+len(extra))
+	attrs = append(attrs,
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = append(attrs, slog.String("peer", p.Addr.String()))
+	}
+	attrs = append(attrs, extra...)
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		attrs = append(attrs, slog.String(requestid.LogKey, id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		attrs = append(attrs,
+			slog.String("trace_id", sc.TraceID().String()),
+			slog.String("span_id", sc.SpanID().String()),
+		)
+	}
+
+	log.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// SlogUnaryInterceptor логирует каждый unary вызов gRPC через переданный *slog.Logger:
+// метод, адрес клиента, код ответа, длительность, request_id и trace_id.
+// Уровень записи зависит от кода ответа (см. GRPCCodeLevel).
+// request_id берётся из контекста, поэтому requestid.UnaryServerInterceptor должен стоять раньше.
+func SlogUnaryInterceptor(log *slog.Logger, opts ...GRPCLogOption) grpc.UnaryServerInterceptor {
+	c := newGRPCLogConfig(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if c.skipped(ctx, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		c.log(ctx, log, "grpc request", info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// SlogStreamInterceptor — аналог SlogUnaryInterceptor для стримов, дополнительно
+// логирует количество принятых и отправленных сообщений.
+func SlogStreamInterceptor(log *slog.Logger, opts ...GRPCLogOption) grpc.StreamServerInterceptor {
+	c := newGRPCLogConfig(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if c.skipped(ss.Context(), info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		start := time.Now()
+		cs := &countingStream{ServerStream: ss}
+		err := handler(srv, cs)
+		c.log(ss.Context(), log, "grpc stream", info.FullMethod, start, err,
+			slog.Int64("recv_msgs", cs.recv.Load()),
+			slog.Int64("sent_msgs", cs.sent.Load()),
+		)
+		return err
+	}
+}
+
+// countingStream считает успешно принятые и отправленные сообщения стрима.
+// Приём и отправка могут идти из разных goroutine, поэтому счётчики атомарные.
+type countingStream struct {
+	grpc.ServerStream
+	recv atomic.Int64
+	sent atomic.Int64
+}
+
+func (s *countingStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.recv.Add(1)
+	}
+	return err
+}
+
+func (s *countingStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.sent.Add(1)
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCLoggingSkip(t *testing.T) {
+	tcpPeer := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+	inProcess := peer.NewContext(context.Background(), &peer.Peer{Addr: bufconnAddr{}})
+
+	tests := []struct {
+		name   string
+		opts   []GRPCLogOption
+		ctx    context.Context
+		method string
+		err    error
+		// want — фрагмент записи; пусто — вызов не логируется
+		want string
+	}{
+		{name: "regular call", ctx: tcpPeer, method: "/orders.OrderService/Get",
+			want: `level=INFO msg="grpc request" method=/orders.OrderService/Get code=OK`},
+		{name: "client error is warn", ctx: tcpPeer, method: "/orders.OrderService/Get", err: status.Error(codes.NotFound, "no order"),
+			want: `level=WARN msg="grpc request" method=/orders.OrderService/Get code=NotFound`},
+		{name: "server error is error", ctx: tcpPeer, method: "/orders.OrderService/Get", err: status.Error(codes.Internal, "db"),
+			want: `level=ERROR msg="grpc request" method=/orders.OrderService/Get code=Internal`},
+		{name: "health skipped by default", ctx: tcpPeer, method: "/grpc.health.v1.Health/Check"},
+		{name: "reflection skipped by default", ctx: tcpPeer, method: "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo"},
+		{name: "custom exact skip", opts: []GRPCLogOption{GRPCLogSkip("/orders.OrderService/Get")}, ctx: tcpPeer,
+			method: "/orders.OrderService/Get"},
+		{name: "custom prefix skip", opts: []GRPCLogOption{GRPCLogSkip("/orders.AdminService/*")}, ctx: tcpPeer,
+			method: "/orders.AdminService/Purge"},
+		{name: "custom skip keeps defaults", opts: []GRPCLogOption{GRPCLogSkip("/orders.AdminService/*")}, ctx: tcpPeer,
+			method: "/grpc.health.v1.Health/Check"},
+		{name: "in-process skipped by default", ctx: inProcess, method: "/orders.OrderService/Get"},
+		{name: "in-process logged on request", opts: []GRPCLogOption{GRPCLogInProcess()}, ctx: inProcess,
+			method: "/orders.OrderService/Get", want: `msg="grpc request" method=/orders.OrderService/Get code=OK`},
+		{name: "custom level", opts: []GRPCLogOption{GRPCLogLevel(func(codes.Code) slog.Level { return slog.LevelDebug })},
+			ctx: tcpPeer, method: "/orders.OrderService/Get", want: `level=DEBUG msg="grpc request"`},
+	}
+	for _, tt := range tests {
+		for _, kind := range []string{"unary", "stream"} {
+			t.Run(tt.name+"/"+kind, func(t *testing.T) {
+				var buf bytes.Buffer
+				log := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+				if kind == "unary" {
+					_, _ = SlogUnaryInterceptor(log, tt.opts...)(tt.ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+						func(context.Context, any) (any, error) { return nil, tt.err })
+				} else {
+					_ = SlogStreamInterceptor(log, tt.opts...)(nil, &fakeServerStream{ctx: tt.ctx}, &grpc.StreamServerInfo{FullMethod: tt.method},
+						func(any, grpc.ServerStream) error { return tt.err })
+				}
+
+				out := buf.String()
+				if tt.want == "" {
+					if out != "" {
+						t.Errorf("skipped call logged: %s", out)
+					}
+					return
+				}
+				want := tt.want
+				if kind == "stream" {
+					want = strings.Replace(want, `msg="grpc request"`, `msg="grpc stream"`, 1)
+				}
+				if !strings.Contains(out, want) {
+					t.Errorf("record %s does not contain %s", out, want)
+				}
+			})
+		}
+	}
+}
@@ -6,13 +6,27 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/vovanwin/platform/requestid"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 func (s *Server) initHTTP(log *slog.Logger) error {
-	gwMux := runtime.NewServeMux()
+	if err := s.initCORS(); err != nil {
+		return err
+	}
+
+	gwMux := runtime.NewServeMux(s.gatewayMuxOptions()...)
+	s.gatewayRoutes = gatewayRouteMethods(s.grpcServer.GetServiceInfo())
+	s.gatewayStreams = gatewayStreamRoutes(s.gatewayRoutes, s.grpcServer.GetServiceInfo())
+	if s.concurrency != nil {
+		s.concurrency.streams = s.gatewayStreams
+	}
 
 	for _, reg := range s.gatewayRegistrators {
 		if err := reg(context.Background(), gwMux, s.grpcServer); err != nil {
@@ -20,28 +34,93 @@ func (s *Server) initHTTP(log *slog.Logger) error {
 		}
 	}
 
+	// Gateway поверх in-process gRPC соединения: REST проходит через gRPC interceptors
+	if len(s.gatewayConnRegs) > 0 {
+		if err := s.initInProcessConn(log); err != nil {
+			return err
+		}
+		for _, reg := range s.gatewayConnRegs {
+			if err := reg(context.Background(), gwMux, s.inProcessConn); err != nil {
+				return fmt.Errorf("register gateway over in-process conn: %w", err)
+			}
+		}
+	}
+
+	for _, p := range s.gatewayPaths {
+		if err := gwMux.HandlePath(p.method, p.pattern, p.handler); err != nil {
+			return fmt.Errorf("gateway handle path %s %s: %w", p.method, p.pattern, err)
+		}
+	}
+
 	r := chi.NewRouter()
 
+	// X-Request-ID: до логирования, чтобы request_id был в логе запроса
+	r.Use(requestid.Middleware())
+
+	// Отладочное логирование по X-Debug-Log: до OTEL middleware, чтобы трейс запроса сэмплировался
+	if s.debugLog != nil {
+		r.Use(s.debugLog.Middleware())
+	}
+
 	// Логирование запросов через slog
-	r.Use(SlogRequestLogger(log))
+	r.Use(SlogRequestLogger(log, s.requestLogOpts...))
+
+	// CORS: preflight отвечается до startup gate, пользовательских middleware и gateway
+	if s.cors != nil {
+		r.Use(s.cors.middleware)
+	}
+
+	// 503 до открытия всех StartupGate
+	if !s.startup.ready.Load() {
+		r.Use(s.startup.httpMiddleware)
+	}
 
 	// Пользовательские middleware
 	for _, mw := range s.httpMiddleware {
 		r.Use(mw)
 	}
 
+	if s.cfg.SinglePort && s.cfg.SinglePortSwagger && s.cfg.SwaggerFS != nil {
+		swagger, _ := s.swaggerHandler(log, "/swagger")
+		r.Mount("/swagger", http.StripPrefix("/swagger", swagger))
+	}
+
 	r.Mount("/", gwMux)
+	s.httpRouter = r
+
+	var handler http.Handler = r
+	if s.cfg.SinglePort {
+		handler = s.singlePortHandler(r)
+	}
 
 	addr := net.JoinHostPort(s.cfg.Host, s.cfg.HTTPPort)
 
-	s.httpServer = &http.Server{
-		Addr:    addr,
-		Handler: r,
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("http gateway listen %s: %w", addr, err)
+	}
+	s.addrs.HTTP = lis.Addr().String()
+	if s.cfg.SinglePort {
+		s.addrs.GRPC = s.addrs.HTTP
+		if s.cfg.SinglePortSwagger && s.cfg.SwaggerFS != nil {
+			s.addrs.Swagger = s.addrs.HTTP
+		}
+	}
+
+	s.httpServer = s.cfg.newHTTPServer(s.addrs.HTTP, handler)
+
+	if s.cfg.HTTPTLS != nil {
+		tlsCfg, err := s.cfg.HTTPTLS.build(log)
+		if err != nil {
+			_ = lis.Close()
+			return fmt.Errorf("http gateway tls: %w", err)
+		}
+		s.httpServer.TLSConfig = tlsCfg
 	}
 
 	go func() {
-		log.Info("HTTP gateway запущен", slog.String("addr", addr))
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Info("HTTP gateway запущен", slog.String("addr", s.addrs.HTTP), slog.Bool("tls", s.cfg.HTTPTLS != nil))
+		if err := serveHTTP(s.httpServer, lis); err != nil && err != http.ErrServerClosed {
 			log.Error("HTTP gateway остановлен с ошибкой", slog.String("error", err.Error()))
 		}
 	}()
@@ -49,10 +128,51 @@ func (s *Server) initHTTP(log *slog.Logger) error {
 	return nil
 }
 
+// singlePortHandler направляет gRPC запросы (HTTP/2 + Content-Type application/grpc*) в gRPC сервер,
+// остальные — в HTTP gateway. Без TLS HTTP/2 принимается через h2c.
+// gRPC в этом режиме работает через grpc.Server.ServeHTTP: keepalive и лимиты транспорта gRPC
+// (GRPCKeepalive) не применяются — соединение ограничивают только ReadHeaderTimeout, IdleTimeout
+// и MaxHeaderBytes http.Server. ReadTimeout и WriteTimeout для gRPC вызовов снимаются: они
+// оборвали бы стримы и долгие вызовы; длительность вызова ограничивает дедлайн клиента.
+func (s *Server) singlePortHandler(gateway http.Handler) http.Handler {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			// Для HTTP/2 дедлайны действуют на отдельный стрим, а не на всё соединение
+			rc := http.NewResponseController(w)
+			_ = rc.SetReadDeadline(time.Time{})
+			_ = rc.SetWriteDeadline(time.Time{})
+			s.grpcServer.ServeHTTP(w, r)
+			return
+		}
+		gateway.ServeHTTP(w, r)
+	})
+
+	if s.cfg.HTTPTLS != nil {
+		// С TLS HTTP/2 согласуется через ALPN в http.Server.ServeTLS
+		return h
+	}
+	return h2c.NewHandler(h, &http2.Server{})
+}
+
+// serveHTTP запускает HTTP сервер на listener, с TLS если задан srv.TLSConfig.
+func serveHTTP(srv *http.Server, lis net.Listener) error {
+	if srv.TLSConfig != nil {
+		return srv.ServeTLS(lis, "", "")
+	}
+	return srv.Serve(lis)
+}
+
 func (s *Server) stopHTTP(ctx context.Context, log *slog.Logger) error {
 	if s.httpServer != nil {
 		log.Info("HTTP gateway завершает работу...")
-		return s.httpServer.Shutdown(ctx)
+		err := shutdownHTTPServer(ctx, s.httpServer)
+		// Gateway больше не шлёт запросы — закрываем in-process соединение к gRPC
+		if s.inProcessConn != nil {
+			_ = s.inProcessConn.Close()
+		}
+		if err != nil {
+			return fmt.Errorf("http gateway shutdown: %w", err)
+		}
 	}
 	return nil
 }
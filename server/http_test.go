@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// TestSinglePortGRPCStreamOutlivesTimeouts проверяет, что ReadTimeout и WriteTimeout HTTP сервера
+// не обрывают gRPC стримы в single-port режиме.
+func TestSinglePortGRPCStreamOutlivesTimeouts(t *testing.T) {
+	const timeout = 300 * time.Millisecond
+
+	tests := []struct {
+		name string
+		tls  bool
+	}{
+		{"h2c", false},
+		{"tls", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := slog.New(slog.NewTextHandler(io.Discard, nil))
+			cfg := Config{
+				Host:         "127.0.0.1",
+				HTTPPort:     "0",
+				SinglePort:   true,
+				ReadTimeout:  timeout,
+				WriteTimeout: timeout,
+			}
+			creds := insecure.NewCredentials()
+			if tt.tls {
+				cfg.HTTPTLS = &TLSConfig{Config: selfSignedTLS(t)}
+				creds = credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})
+			}
+
+			s := newServer(cfg)
+			s.initStartup()
+			if err := s.initGRPC(log); err != nil {
+				t.Fatalf("initGRPC: %v", err)
+			}
+			if err := s.initHTTP(log); err != nil {
+				t.Fatalf("initHTTP: %v", err)
+			}
+			t.Cleanup(func() { _ = s.httpServer.Close() })
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			stream, err := healthpb.NewHealthClient(dial(t, s.addrs.HTTP, creds)).Watch(ctx, &healthpb.HealthCheckRequest{})
+			if err != nil {
+				t.Fatalf("Watch: %v", err)
+			}
+			if _, err := stream.Recv(); err != nil {
+				t.Fatalf("first Recv: %v", err)
+			}
+
+			time.Sleep(3 * timeout)
+			s.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+			resp, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("Recv after %v: %v", 3*timeout, err)
+			}
+			if resp.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+				t.Errorf("status = %v, want NOT_SERVING", resp.GetStatus())
+			}
+		})
+	}
+}
+
+// TestSinglePortRouting проверяет, что в single-port режиме gRPC вызовы уходят в gRPC сервер,
+// а REST по HTTP/1.1 и HTTP/2 — в gateway.
+func TestSinglePortRouting(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ping := func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		_, _ = fmt.Fprintf(w, "pong %s", r.Proto)
+	}
+
+	transports := []struct {
+		name  string
+		tls   bool
+		creds credentials.TransportCredentials
+	}{
+		{"h2c", false, insecure.NewCredentials()},
+		{"tls", true, credentials.NewTLS(&tls.Config{InsecureSkipVerify: true})},
+	}
+	for _, tr := range transports {
+		t.Run(tr.name, func(t *testing.T) {
+			cfg := Config{Host: "127.0.0.1", HTTPPort: "0", SinglePort: true}
+			if tr.tls {
+				cfg.HTTPTLS = &TLSConfig{Config: selfSignedTLS(t)}
+			}
+			s := newServer(cfg, WithGatewayHandlePath(http.MethodGet, "/v1/ping", ping))
+			s.initStartup()
+			if err := s.initGRPC(log); err != nil {
+				t.Fatalf("initGRPC: %v", err)
+			}
+			if err := s.initHTTP(log); err != nil {
+				t.Fatalf("initHTTP: %v", err)
+			}
+			t.Cleanup(func() { _ = s.httpServer.Close() })
+			if s.addrs.GRPC != s.addrs.HTTP {
+				t.Fatalf("gRPC addr %s, want the HTTP addr %s", s.addrs.GRPC, s.addrs.HTTP)
+			}
+
+			scheme := "http"
+			if tr.tls {
+				scheme = "https"
+			}
+			tlsCfg := &tls.Config{InsecureSkipVerify: true}
+			h1 := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsCfg}}
+			h2 := &http.Client{Transport: &http2.Transport{
+				TLSClientConfig: tlsCfg,
+				AllowHTTP:       true,
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+					if tr.tls {
+						return (&tls.Dialer{Config: cfg}).DialContext(ctx, network, addr)
+					}
+					return (&net.Dialer{}).DialContext(ctx, network, addr)
+				},
+			}}
+
+			tests := []struct {
+				name string
+				call func(t *testing.T) string
+				want string
+			}{
+				{"grpc", func(t *testing.T) string {
+					ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+					defer cancel()
+					resp, err := healthpb.NewHealthClient(dial(t, s.addrs.HTTP, tr.creds)).Check(ctx, &healthpb.HealthCheckRequest{})
+					if err != nil {
+						t.Fatalf("Check: %v", err)
+					}
+					return resp.GetStatus().String()
+				}, "SERVING"},
+				{"rest http/1.1", func(t *testing.T) string { return getBody(t, h1, scheme+"://"+s.addrs.HTTP+"/v1/ping") }, "pong HTTP/1.1"},
+				{"rest http/2", func(t *testing.T) string { return getBody(t, h2, scheme+"://"+s.addrs.HTTP+"/v1/ping") }, "pong HTTP/2.0"},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					if got := tt.call(t); got != tt.want {
+						t.Errorf("got %q, want %q", got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func getBody(t *testing.T, c *http.Client, url string) string {
+	t.Helper()
+	resp, err := c.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return string(body)
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// inProcessBufSize — размер буфера in-memory соединения gateway → gRPC.
+const inProcessBufSize = 1 << 20
+
+// initInProcessConn поднимает in-memory listener для gRPC сервера и клиентское соединение к нему.
+// Через это соединение grpc-gateway вызывает сервисы как обычный gRPC клиент, поэтому REST запросы
+// проходят через все interceptors и stats handlers gRPC сервера.
+func (s *Server) initInProcessConn(log *slog.Logger) error {
+	lis := bufconn.Listen(inProcessBufSize)
+
+	go func() {
+		if err := s.grpcServer.Serve(lis); err != nil {
+			log.Error("In-process gRPC listener остановлен с ошибкой", slog.String("error", err.Error()))
+		}
+	}()
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		// Лимиты клиента совпадают с лимитами сервера, иначе крупные ответы упрутся в 4MB клиента по умолчанию
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallSendMsgSize(s.grpcMaxRecvMsgSize()),
+			grpc.MaxCallRecvMsgSize(s.grpcMaxSendMsgSize()),
+		),
+	}
+	if s.debugLog != nil {
+		// Токен отладочного запроса доходит до gRPC обработчика и без OTEL propagation
+		dialOpts = append(dialOpts,
+			grpc.WithChainUnaryInterceptor(s.debugLog.UnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(s.debugLog.StreamClientInterceptor()),
+		)
+	}
+	if s.auth != nil {
+		// Отметка PublicPaths из HTTP middleware доходит до auth.Policy в gRPC interceptor
+		dialOpts = append(dialOpts,
+			grpc.WithChainUnaryInterceptor(publicPathUnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(publicPathStreamClientInterceptor()),
+		)
+	}
+	if s.otelCfg != nil {
+		// Пробрасываем trace context из HTTP спана в gRPC метаданные
+		dialOpts = append(dialOpts, grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	}
+
+	conn, err := grpc.NewClient("passthrough:///inprocess", dialOpts...)
+	if err != nil {
+		_ = lis.Close()
+		return fmt.Errorf("in-process grpc dial: %w", err)
+	}
+
+	s.inProcessConn = conn
+	log.Info("HTTP gateway подключён к gRPC серверу через in-process соединение")
+
+	return nil
+}
+
+// inProcessCreds — транспортные credentials gRPC сервера с Config.GRPCTLS: соединения in-process
+// listener (bufconn) принимаются без TLS, остальные — через TLS. grpc.Creds действует на все listener-ы
+// сервера, а клиентского сертификата для mTLS у gateway нет. Соединение bufconn не покидает процесс.
+type inProcessCreds struct {
+	credentials.TransportCredentials
+}
+
+func (c inProcessCreds) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	if conn.RemoteAddr().Network() == "bufconn" {
+		return insecure.NewCredentials().ServerHandshake(conn)
+	}
+	return c.TransportCredentials.ServerHandshake(conn)
+}
+
+func (c inProcessCreds) Clone() credentials.TransportCredentials {
+	return inProcessCreds{c.TransportCredentials.Clone()}
+}
+
+// isInProcess сообщает, пришёл ли gRPC вызов от gateway через in-process соединение.
+// Такие вызовы уже прошли HTTP middleware и gateway middleware.
+func isInProcess(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	return ok && p.Addr != nil && p.Addr.Network() == "bufconn"
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// selfSignedTLS возвращает серверный tls.Config с самоподписанным сертификатом для 127.0.0.1.
+func selfSignedTLS(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func TestInProcessConnWithGRPCTLS(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := newServer(Config{
+		Host:     "127.0.0.1",
+		GRPCPort: "0",
+		GRPCTLS:  &TLSConfig{Config: selfSignedTLS(t)},
+	})
+	if err := s.initGRPC(log); err != nil {
+		t.Fatalf("initGRPC: %v", err)
+	}
+	t.Cleanup(s.grpcServer.Stop)
+	if err := s.initInProcessConn(log); err != nil {
+		t.Fatalf("initInProcessConn: %v", err)
+	}
+	t.Cleanup(func() { _ = s.inProcessConn.Close() })
+
+	tests := []struct {
+		name    string
+		conn    func(t *testing.T) *grpc.ClientConn
+		wantErr bool
+	}{
+		{"in-process", func(*testing.T) *grpc.ClientConn { return s.inProcessConn }, false},
+		{"tcp with tls", func(t *testing.T) *grpc.ClientConn {
+			return dial(t, s.addrs.GRPC, credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}))
+		}, false},
+		{"tcp plaintext", func(t *testing.T) *grpc.ClientConn {
+			return dial(t, s.addrs.GRPC, insecure.NewCredentials())
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			_, err := healthpb.NewHealthClient(tt.conn(t)).Check(ctx, &healthpb.HealthCheckRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func dial(t *testing.T, addr string, creds credentials.TransportCredentials) *grpc.ClientConn {
+	t.Helper()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		t.Fatalf("dial %s: %v", addr, err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+const (
+	defaultGRPCMaxConnectionIdle     = 15 * time.Minute
+	defaultGRPCMaxConnectionAge      = 30 * time.Minute
+	defaultGRPCMaxConnectionAgeGrace = 30 * time.Second
+	defaultGRPCKeepaliveTime         = time.Minute
+	defaultGRPCKeepaliveTimeout      = 20 * time.Second
+	defaultGRPCKeepaliveMinTime      = 10 * time.Second
+)
+
+// GRPCKeepaliveConfig — keepalive и время жизни соединений gRPC сервера.
+// Нулевое значение поля — значение по умолчанию, отрицательная длительность — без ограничения.
+// В single-port режиме не применяется (gRPC обслуживает http.Server).
+type GRPCKeepaliveConfig struct {
+	// MaxConnectionIdle — соединение без активных вызовов закрывается через GOAWAY. 0 — 15m.
+	MaxConnectionIdle time.Duration
+	// MaxConnectionAge — максимальный возраст соединения (gRPC добавляет ±10% jitter), после него
+	// сервер отправляет GOAWAY, и клиент переподключается — в том числе к новым подам после scale-out. 0 — 30m.
+	MaxConnectionAge time.Duration
+	// MaxConnectionAgeGrace — сколько после GOAWAY по возрасту ждать завершения текущих вызовов,
+	// прежде чем закрыть соединение принудительно. 0 — 30s.
+	MaxConnectionAgeGrace time.Duration
+	// Time — интервал ping от сервера при отсутствии активности, чтобы обнаружить мёртвых клиентов. 0 — 1m.
+	Time time.Duration
+	// Timeout — ожидание ответа на ping, после которого соединение закрывается. 0 — 20s.
+	Timeout time.Duration
+
+	// MinTime — минимальный интервал keepalive ping от клиента; клиенты, пингующие чаще,
+	// получают GOAWAY (too_many_pings). 0 — 10s (у gRPC по умолчанию 5m).
+	MinTime time.Duration
+	// ForbidPingWithoutStream — запретить ping от клиента без активных вызовов.
+	// По умолчанию разрешены, чтобы клиенты с keepalive не получали GOAWAY на простое.
+	ForbidPingWithoutStream bool
+
+	// MaxConcurrentStreams — лимит одновременных вызовов на одно соединение. 0 — без ограничения.
+	MaxConcurrentStreams uint32
+}
+
+// infinityOr переводит длительность в формат keepalive: 0 — def, отрицательная — без ограничения.
+func infinityOr(d, def time.Duration) time.Duration {
+	switch {
+	case d == 0:
+		return def
+	case d < 0:
+		// keepalive трактует 0 как значение по умолчанию gRPC, поэтому «без ограничения» — максимальная длительность
+		return time.Duration(1<<63 - 1)
+	}
+	return d
+}
+
+// minTime — для enforcement 0 означает 5m по умолчанию gRPC, поэтому «без ограничения» — 1ns.
+func (c GRPCKeepaliveConfig) minTime() time.Duration {
+	switch {
+	case c.MinTime == 0:
+		return defaultGRPCKeepaliveMinTime
+	case c.MinTime < 0:
+		return time.Nanosecond
+	}
+	return c.MinTime
+}
+
+// serverOptions возвращает опции gRPC сервера для keepalive, возраста соединений и лимита стримов.
+func (c GRPCKeepaliveConfig) serverOptions() []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     infinityOr(c.MaxConnectionIdle, defaultGRPCMaxConnectionIdle),
+			MaxConnectionAge:      infinityOr(c.MaxConnectionAge, defaultGRPCMaxConnectionAge),
+			MaxConnectionAgeGrace: infinityOr(c.MaxConnectionAgeGrace, defaultGRPCMaxConnectionAgeGrace),
+			Time:                  infinityOr(c.Time, defaultGRPCKeepaliveTime),
+			Timeout:               infinityOr(c.Timeout, defaultGRPCKeepaliveTimeout),
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             c.minTime(),
+			PermitWithoutStream: !c.ForbidPingWithoutStream,
+		}),
+	}
+	if c.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(c.MaxConcurrentStreams))
+	}
+	return opts
+}
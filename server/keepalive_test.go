@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestGRPCKeepaliveMapping(t *testing.T) {
+	const infinity = time.Duration(1<<63 - 1)
+
+	tests := []struct {
+		name string
+		got  time.Duration
+		want time.Duration
+	}{
+		{"zero is default", infinityOr(0, time.Minute), time.Minute},
+		{"negative is unlimited", infinityOr(-1, time.Minute), infinity},
+		{"positive as is", infinityOr(5*time.Second, time.Minute), 5 * time.Second},
+		{"min time default", GRPCKeepaliveConfig{}.minTime(), defaultGRPCKeepaliveMinTime},
+		{"min time unlimited", GRPCKeepaliveConfig{MinTime: -1}.minTime(), time.Nanosecond},
+		{"min time as is", GRPCKeepaliveConfig{MinTime: time.Second}.minTime(), time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGRPCMaxConnectionAge проверяет, что сервер закрывает соединение по возрасту и простою (GOAWAY),
+// а без ограничения — держит его.
+func TestGRPCMaxConnectionAge(t *testing.T) {
+	tests := []struct {
+		name      string
+		keepalive GRPCKeepaliveConfig
+		wantClose bool
+	}{
+		{"max connection age", GRPCKeepaliveConfig{MaxConnectionAge: 100 * time.Millisecond, MaxConnectionAgeGrace: 10 * time.Millisecond}, true},
+		{"max connection idle", GRPCKeepaliveConfig{MaxConnectionIdle: 100 * time.Millisecond}, true},
+		{"defaults", GRPCKeepaliveConfig{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			log := slog.New(slog.NewTextHandler(io.Discard, nil))
+			s := newServer(Config{Host: "127.0.0.1", GRPCPort: "0", GRPCKeepalive: tt.keepalive})
+			s.initStartup()
+			if err := s.initGRPC(log); err != nil {
+				t.Fatalf("initGRPC: %v", err)
+			}
+			t.Cleanup(s.grpcServer.Stop)
+
+			conn := dial(t, s.addrs.GRPC, insecure.NewCredentials())
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+
+			// GOAWAY переводит соединение клиента из READY в IDLE
+			waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+			defer waitCancel()
+			closed := conn.WaitForStateChange(waitCtx, connectivity.Ready)
+			if closed != tt.wantClose {
+				t.Errorf("connection closed = %v (state %v), want %v", closed, conn.GetState(), tt.wantClose)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	platformerrors "github.com/vovanwin/platform/errors"
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultReadHeaderTimeout   = 10 * time.Second
+	defaultReadTimeout         = time.Minute
+	defaultWriteTimeout        = time.Minute
+	defaultIdleTimeout         = 2 * time.Minute
+	defaultMaxHeaderBytes      = 1 << 20
+	defaultMaxRequestBodyBytes = 4 << 20
+)
+
+// durationOr возвращает def для 0 и 0 (без ограничения) для отрицательного значения.
+func durationOr(d, def time.Duration) time.Duration {
+	switch {
+	case d == 0:
+		return def
+	case d < 0:
+		return 0
+	}
+	return d
+}
+
+func (c Config) readHeaderTimeout() time.Duration {
+	return durationOr(c.ReadHeaderTimeout, defaultReadHeaderTimeout)
+}
+
+func (c Config) readTimeout() time.Duration {
+	return durationOr(c.ReadTimeout, defaultReadTimeout)
+}
+
+func (c Config) writeTimeout() time.Duration {
+	return durationOr(c.WriteTimeout, defaultWriteTimeout)
+}
+
+func (c Config) idleTimeout() time.Duration {
+	return durationOr(c.IdleTimeout, defaultIdleTimeout)
+}
+
+func (c Config) maxHeaderBytes() int {
+	if c.MaxHeaderBytes <= 0 {
+		return defaultMaxHeaderBytes
+	}
+	return c.MaxHeaderBytes
+}
+
+// maxRequestBodyBytes возвращает лимит тела запроса; 0 — без ограничения.
+func (c Config) maxRequestBodyBytes() int64 {
+	switch {
+	case c.MaxRequestBodyBytes == 0:
+		return defaultMaxRequestBodyBytes
+	case c.MaxRequestBodyBytes < 0:
+		return 0
+	}
+	return c.MaxRequestBodyBytes
+}
+
+// newHTTPServer создаёт http.Server с таймаутами и лимитом заголовков из Config.
+func (c Config) newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: c.readHeaderTimeout(),
+		ReadTimeout:       c.readTimeout(),
+		WriteTimeout:      c.writeTimeout(),
+		IdleTimeout:       c.idleTimeout(),
+		MaxHeaderBytes:    c.maxHeaderBytes(),
+	}
+}
+
+// requestBodyLimit возвращает лимит тела для маршрута "METHOD /pattern"; 0 — без ограничения.
+func (s *Server) requestBodyLimit(route string) int64 {
+	if limit, ok := s.bodyLimits[route]; ok {
+		return max(limit, 0)
+	}
+	return s.cfg.maxRequestBodyBytes()
+}
+
+// maxMsgSize — наибольший размер сообщения gRPC (math.MaxInt32).
+const maxMsgSize = 1<<31 - 1
+
+// grpcMaxRecvMsgSize — лимит входящего gRPC сообщения: не меньше наибольшего лимита тела HTTP,
+// иначе REST запрос, пропущенный gateway, отклонит gRPC сервер.
+func (s *Server) grpcMaxRecvMsgSize() int {
+	limit := s.cfg.maxRequestBodyBytes()
+	if limit == 0 {
+		return maxMsgSize
+	}
+	for _, l := range s.bodyLimits {
+		if l < 0 {
+			return maxMsgSize
+		}
+		limit = max(limit, l)
+	}
+	return int(min(limit, maxMsgSize))
+}
+
+// grpcMaxSendMsgSize — лимит исходящего gRPC сообщения. По умолчанию gRPC не ограничивает ответы.
+func (s *Server) grpcMaxSendMsgSize() int {
+	if s.cfg.MaxResponseBodyBytes <= 0 {
+		return maxMsgSize
+	}
+	return int(min(s.cfg.MaxResponseBodyBytes, maxMsgSize))
+}
+
+// initRequestLimits подключает лимит тела запроса к grpc-gateway и переносит лимиты
+// размера в gRPC сервер. Пользовательские WithGRPCOptions применяются позже и могут их переопределить.
+func (s *Server) initRequestLimits() error {
+	for route, limit := range s.bodyLimits {
+		if limit == 0 {
+			return fmt.Errorf("request body limit %q: must not be zero", route)
+		}
+	}
+
+	s.gatewayMiddleware = append(s.gatewayMiddleware, s.streamDeadlineMiddleware, s.bodyLimitMiddleware)
+
+	s.grpcOptions = append([]grpc.ServerOption{
+		grpc.MaxRecvMsgSize(s.grpcMaxRecvMsgSize()),
+		grpc.MaxSendMsgSize(s.grpcMaxSendMsgSize()),
+	}, s.grpcOptions...)
+	return nil
+}
+
+// streamDeadlineMiddleware снимает ReadTimeout и WriteTimeout с маршрутов gateway, за которыми стоят
+// streaming gRPC методы (как singlePortHandler для gRPC вызовов): стрим живёт дольше таймаутов,
+// его длительность ограничивают контекст запроса и закрытие соединения клиентом.
+func (s *Server) streamDeadlineMiddleware(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		if _, ok := s.gatewayStreams[gatewayRoute(r)]; ok {
+			rc := http.NewResponseController(w)
+			_ = rc.SetReadDeadline(time.Time{})
+			_ = rc.SetWriteDeadline(time.Time{})
+		}
+		next(w, r, pathParams)
+	}
+}
+
+// bodyLimitMiddleware ограничивает тело запроса grpc-gateway и отвечает 413.
+// Запрос с Content-Length больше лимита отклоняется сразу; тело без Content-Length (chunked)
+// обрезается при чтении, и ответ gateway об ошибке декодирования заменяется на 413.
+func (s *Server) bodyLimitMiddleware(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		limit := s.requestBodyLimit(gatewayRoute(r))
+		if limit <= 0 || r.Body == nil || r.Body == http.NoBody {
+			next(w, r, pathParams)
+			return
+		}
+		if r.ContentLength > limit {
+			writeBodyTooLarge(w, r, limit)
+			return
+		}
+
+		body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit)}
+		r.Body = body
+		next(&bodyLimitWriter{ResponseWriter: w, r: r, body: body, limit: limit}, r, pathParams)
+	}
+}
+
+func writeBodyTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	err := &runtime.HTTPStatusError{
+		HTTPStatus: http.StatusRequestEntityTooLarge,
+		Err: platformerrors.ResourceExhausted("request body exceeds %d bytes", limit).
+			WithReason("REQUEST_TOO_LARGE"),
+	}
+	platformerrors.WriteProblem(w, platformerrors.NewProblem(r.Context(), w, r, err))
+}
+
+// limitedBody запоминает, что тело запроса превысило лимит.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// bodyLimitWriter заменяет ответ gateway на 413, если обработчик не смог прочитать тело из-за лимита.
+type bodyLimitWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	body     *limitedBody
+	limit    int64
+	wrote    bool
+	replaced bool
+}
+
+func (w *bodyLimitWriter) WriteHeader(code int) {
+	if w.wrote {
+		return
+	}
+	w.wrote = true
+	if w.body.exceeded {
+		w.replaced = true
+		writeBodyTooLarge(w.ResponseWriter, w.r, w.limit)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *bodyLimitWriter) Write(b []byte) (int, error) {
+	if !w.wrote {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.replaced {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyLimitWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && !w.replaced {
+		f.Flush()
+	}
+}
+
+func (w *bodyLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
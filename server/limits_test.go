@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		opts    []Option
+		size    int
+		chunked bool
+		// wantNext — запрос дошёл до gateway
+		wantNext   bool
+		wantStatus int
+	}{
+		{name: "under limit", cfg: Config{MaxRequestBodyBytes: 10}, size: 10, wantNext: true, wantStatus: http.StatusOK},
+		{name: "content length over limit", cfg: Config{MaxRequestBodyBytes: 10}, size: 11, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "chunked under limit", cfg: Config{MaxRequestBodyBytes: 10}, size: 10, chunked: true, wantNext: true, wantStatus: http.StatusOK},
+		{name: "chunked over limit", cfg: Config{MaxRequestBodyBytes: 10}, size: 11, chunked: true, wantNext: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "default limit", size: defaultMaxRequestBodyBytes + 1, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "unlimited", cfg: Config{MaxRequestBodyBytes: -1}, size: defaultMaxRequestBodyBytes + 1, wantNext: true, wantStatus: http.StatusOK},
+		{
+			name:       "route limit raises default",
+			cfg:        Config{MaxRequestBodyBytes: 10},
+			opts:       []Option{WithRequestBodyLimit("POST /v1/upload", 100)},
+			size:       100,
+			chunked:    true,
+			wantNext:   true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "route limit lowers default",
+			cfg:        Config{MaxRequestBodyBytes: 100},
+			opts:       []Option{WithRequestBodyLimit("POST /v1/upload", 10)},
+			size:       11,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "route without limit",
+			cfg:        Config{MaxRequestBodyBytes: 10},
+			opts:       []Option{WithRequestBodyLimit("POST /v1/upload", -1)},
+			size:       1000,
+			wantNext:   true,
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(tt.cfg, tt.opts...)
+			next := false
+			// Как gateway: ошибка чтения тела — 400
+			h := s.bodyLimitMiddleware(func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+				next = true
+				if _, err := io.ReadAll(r.Body); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			var body io.Reader = strings.NewReader(strings.Repeat("x", tt.size))
+			if tt.chunked {
+				body = io.MultiReader(body) // без Content-Length
+			}
+			r := httptest.NewRequest(http.MethodPost, "/v1/upload", body)
+			rec := httptest.NewRecorder()
+			h(rec, r, nil)
+
+			if next != tt.wantNext {
+				t.Errorf("next called = %v, want %v", next, tt.wantNext)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %q)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusRequestEntityTooLarge {
+				return
+			}
+			var problem struct {
+				Status int    `json:"status"`
+				Reason string `json:"reason"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("decode problem %q: %v", rec.Body.String(), err)
+			}
+			if problem.Status != http.StatusRequestEntityTooLarge || problem.Reason != "REQUEST_TOO_LARGE" {
+				t.Errorf("problem = %+v", problem)
+			}
+		})
+	}
+}
+
+func TestGRPCMaxRecvMsgSize(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		opts []Option
+		want int
+	}{
+		{"default", Config{}, nil, defaultMaxRequestBodyBytes},
+		{"unlimited", Config{MaxRequestBodyBytes: -1}, nil, maxMsgSize},
+		{"largest route limit", Config{MaxRequestBodyBytes: 10}, []Option{WithRequestBodyLimit("POST /v1/upload", 100)}, 100},
+		{"route without limit", Config{MaxRequestBodyBytes: 10}, []Option{WithRequestBodyLimit("POST /v1/upload", -1)}, maxMsgSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newServer(tt.cfg, tt.opts...).grpcMaxRecvMsgSize(); got != tt.want {
+				t.Errorf("grpcMaxRecvMsgSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+var (
+	tickerProtoOnce sync.Once
+	tickerProtoErr  error
+)
+
+// registerTickerProto регистрирует дескриптор сервиса platform.test.Ticker без сгенерированного кода:
+// server streaming метод Watch с аннотацией GET /v1/ticks.
+func registerTickerProto(t *testing.T) {
+	t.Helper()
+	tickerProtoOnce.Do(func() {
+		opts := &descriptorpb.MethodOptions{}
+		proto.SetExtension(opts, annotations.E_Http, &annotations.HttpRule{
+			Pattern: &annotations.HttpRule_Get{Get: "/v1/ticks"},
+		})
+		fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+			Name:       proto.String("platform/test/ticker.proto"),
+			Package:    proto.String("platform.test"),
+			Syntax:     proto.String("proto3"),
+			Dependency: []string{"google/protobuf/empty.proto"},
+			Service: []*descriptorpb.ServiceDescriptorProto{{
+				Name: proto.String("Ticker"),
+				Method: []*descriptorpb.MethodDescriptorProto{{
+					Name:            proto.String("Watch"),
+					InputType:       proto.String(".google.protobuf.Empty"),
+					OutputType:      proto.String(".google.protobuf.Empty"),
+					ServerStreaming: proto.Bool(true),
+					Options:         opts,
+				}},
+			}},
+		}, protoregistry.GlobalFiles)
+		if err != nil {
+			tickerProtoErr = err
+			return
+		}
+		tickerProtoErr = protoregistry.GlobalFiles.RegisterFile(fd)
+	})
+	if tickerProtoErr != nil {
+		t.Fatalf("register ticker proto: %v", tickerProtoErr)
+	}
+}
+
+// registerTicker регистрирует gRPC сервис platform.test.Ticker с пустым стримом Watch.
+func registerTicker(s *grpc.Server) {
+	s.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "platform.test.Ticker",
+		HandlerType: (*any)(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "Watch",
+			ServerStreams: true,
+			Handler:       func(any, grpc.ServerStream) error { return nil },
+		}},
+	}, struct{}{})
+}
+
+// TestGatewayStreamOutlivesWriteTimeout проверяет, что WriteTimeout не обрывает маршрут gateway
+// streaming метода и по-прежнему действует на остальные маршруты.
+func TestGatewayStreamOutlivesWriteTimeout(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+	registerTickerProto(t)
+
+	// Пишет первую часть, затем вторую — уже после WriteTimeout
+	slow := func(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+		_, _ = io.WriteString(w, "tick\n")
+		http.NewResponseController(w).Flush()
+		time.Sleep(3 * timeout)
+		_, _ = io.WriteString(w, "tock\n")
+	}
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := newServer(Config{Host: "127.0.0.1", GRPCPort: "0", HTTPPort: "0", WriteTimeout: timeout},
+		WithGRPCRegistrator(registerTicker),
+		WithGatewayRegistrator(func(_ context.Context, mux *runtime.ServeMux, _ *grpc.Server) error {
+			return mux.HandlePath(http.MethodGet, "/v1/ticks", slow)
+		}),
+		WithGatewayHandlePath(http.MethodGet, "/v1/slow", slow),
+	)
+	s.initStartup()
+	if err := s.initRequestLimits(); err != nil {
+		t.Fatalf("initRequestLimits: %v", err)
+	}
+	if err := s.initGRPC(log); err != nil {
+		t.Fatalf("initGRPC: %v", err)
+	}
+	t.Cleanup(s.grpcServer.Stop)
+	if err := s.initHTTP(log); err != nil {
+		t.Fatalf("initHTTP: %v", err)
+	}
+	t.Cleanup(func() { _ = s.httpServer.Close() })
+
+	tests := []struct {
+		name     string
+		path     string
+		wantBody string
+		wantErr  bool
+	}{
+		{"stream route", "/v1/ticks", "tick\ntock\n", false},
+		{"unary route", "/v1/slow", "tick\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get("http://" + s.addrs.HTTP + tt.path)
+			if err != nil {
+				t.Fatalf("GET: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("read body error = %v, want error %v", err, tt.wantErr)
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vovanwin/platform/requestid"
 )
 
 type responseWriter struct {
 	http.ResponseWriter
-	status int
-	bytes  int
+	status  int
+	bytes   int
+	capture *capture
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -20,26 +24,106 @@ func (rw *responseWriter) WriteHeader(code int) {
 func (rw *responseWriter) Write(b []byte) (int, error) {
 	n, err := rw.ResponseWriter.Write(b)
 	rw.bytes += n
+	if rw.capture != nil {
+		rw.capture.write(b[:n])
+	}
 	return n, err
 }
 
+// Flush нужен grpc-gateway для server-streaming ответов.
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного ResponseWriter.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // SlogRequestLogger возвращает chi-совместимый middleware, который логирует
-// каждый HTTP-запрос через переданный *slog.Logger.
-func SlogRequestLogger(log *slog.Logger) func(http.Handler) http.Handler {
+// каждый HTTP-запрос через переданный *slog.Logger: метод, шаблон маршрута (chi или grpc-gateway,
+// без шаблона — путь), статус, размеры запроса и ответа, длительность, IP и User-Agent клиента.
+// Уровень записи зависит от статуса (см. HTTPStatusLevel), поведение настраивается RequestLogOption.
+// request_id берётся из контекста, поэтому requestid.Middleware должен стоять раньше.
+func SlogRequestLogger(log *slog.Logger, opts ...RequestLogOption) func(http.Handler) http.Handler {
+	c := newRequestLogConfig(opts)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if c.skipped(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			start := time.Now()
 
+			ctx, route := withRouteHolder(r.Context())
+			r = r.WithContext(ctx)
+
+			var reqBody *countingBody
+			if r.Body != nil && r.Body != http.NoBody {
+				reqBody = &countingBody{ReadCloser: r.Body}
+				r.Body = reqBody
+			}
+
 			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			if c.bodies > 0 {
+				rw.capture = &capture{limit: c.bodies}
+				if reqBody != nil {
+					reqBody.capture = &capture{limit: c.bodies}
+				}
+			}
+
 			next.ServeHTTP(rw, r)
 
-			log.InfoContext(r.Context(), "http request",
+			level := c.level(rw.status)
+			if !c.sampled(rw.status) || !log.Enabled(ctx, level) {
+				return
+			}
+
+			var requestBytes int64
+			if reqBody != nil {
+				requestBytes = reqBody.n
+			}
+
+			attrs := make([]slog.Attr, 0, 12)
+			attrs = append(attrs,
 				slog.String("method", r.Method),
-				slog.String("path", r.URL.Path),
+				slog.String("route", requestRoute(r, route)),
 				slog.Int("status", rw.status),
+				slog.Int64("request_bytes", requestBytes),
 				slog.Int("bytes", rw.bytes),
 				slog.Duration("duration", time.Since(start)),
+				slog.String("ip", clientIP(r)),
+				slog.String("user_agent", r.UserAgent()),
+				slog.String(requestid.LogKey, requestid.FromContext(ctx)),
 			)
+			if c.bodies > 0 {
+				if reqBody != nil {
+					if a, ok := c.bodyAttr("request_body", r.Header.Get("Content-Type"), reqBody.capture.buf.Bytes(), reqBody.capture.truncated); ok {
+						attrs = append(attrs, a)
+					}
+				}
+				if a, ok := c.bodyAttr("response_body", rw.Header().Get("Content-Type"), rw.capture.buf.Bytes(), rw.capture.truncated); ok {
+					attrs = append(attrs, a)
+				}
+			}
+
+			log.LogAttrs(ctx, level, "http request", attrs...)
 		})
 	}
 }
+
+// requestRoute возвращает шаблон маршрута: из grpc-gateway, затем из chi, иначе путь запроса.
+func requestRoute(r *http.Request, route *routeHolder) string {
+	if route.pattern != "" {
+		return route.pattern
+	}
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if p := rctx.RoutePattern(); p != "" && p != "/*" {
+			return p
+		}
+	}
+	return r.URL.Path
+}
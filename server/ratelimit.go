@@ -0,0 +1,312 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/vovanwin/platform/auth"
+	platformerrors "github.com/vovanwin/platform/errors"
+	platformotel "github.com/vovanwin/platform/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// rateLimitSweepInterval — как часто удалять bucket-ы неактивных клиентов.
+const rateLimitSweepInterval = time.Minute
+
+// RateLimit — лимит token bucket: Rate запросов в секунду со всплеском до Burst.
+type RateLimit struct {
+	// Rate — скорость пополнения, запросов в секунду. 0 — без ограничений.
+	Rate float64
+	// Burst — ёмкость bucket, сколько запросов можно сделать подряд. 0 — max(1, ceil(Rate)).
+	Burst int
+}
+
+func (l RateLimit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+// RateLimitConfig — настройки WithRateLimit.
+type RateLimitConfig struct {
+	// Routes — лимиты по маршруту: "METHOD /pattern" для HTTP gateway (напр. "GET /v1/users/{id}")
+	// или полный gRPC метод (напр. "/users.UserService/GetUser").
+	Routes map[string]RateLimit
+	// Default — лимит для маршрутов без правила в Routes. Нулевой — без ограничений.
+	// На gRPC health и reflection не действует.
+	Default RateLimit
+	// Key — ключ клиента, для каждого ключа ведётся свой bucket. nil — RateLimitByIP().
+	Key RateLimitKeyFunc
+}
+
+func (c RateLimitConfig) validate() error {
+	var errs []error
+	if c.Default.Rate < 0 || c.Default.Burst < 0 {
+		errs = append(errs, errors.New("Default: Rate and Burst must not be negative"))
+	}
+	for route, l := range c.Routes {
+		if l.Rate < 0 || l.Burst < 0 {
+			errs = append(errs, fmt.Errorf("route %q: Rate and Burst must not be negative", route))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RateLimitRequest — данные запроса для вычисления ключа клиента.
+type RateLimitRequest struct {
+	// Route — маршрут, как в RateLimitConfig.Routes.
+	Route string
+	// IP — адрес клиента без порта.
+	IP string
+	// Header — HTTP заголовки или gRPC метаданные (ключи в канонической форме http.Header).
+	Header http.Header
+	// Claims — проверенные claims JWT (WithAuth); nil — запрос без аутентификации.
+	Claims *auth.Claims
+}
+
+// RateLimitKeyFunc возвращает ключ клиента. Пустая строка — запрос не ограничивается.
+type RateLimitKeyFunc func(req RateLimitRequest) string
+
+// RateLimitByIP ограничивает по IP клиента.
+func RateLimitByIP() RateLimitKeyFunc {
+	return func(req RateLimitRequest) string {
+		return "ip:" + req.IP
+	}
+}
+
+// RateLimitByHeader ограничивает по значению заголовка (метаданных), напр. "X-API-Key".
+// Запросы без заголовка ограничиваются по IP.
+func RateLimitByHeader(name string) RateLimitKeyFunc {
+	return func(req RateLimitRequest) string {
+		if v := req.Header.Get(name); v != "" {
+			return "header:" + v
+		}
+		return "ip:" + req.IP
+	}
+}
+
+// RateLimitByJWTSubject ограничивает по claim "sub" токена, проверенного WithAuth: rate limiter
+// стоит после аутентификации. Непроверенный токен ключом не служит — иначе каждый запрос
+// с поддельным "sub" получал бы новый bucket. Запросы без claims ограничиваются по IP.
+func RateLimitByJWTSubject() RateLimitKeyFunc {
+	return func(req RateLimitRequest) string {
+		if req.Claims != nil && req.Claims.Subject != "" {
+			return "sub:" + req.Claims.Subject
+		}
+		return "ip:" + req.IP
+	}
+}
+
+// rateLimiter хранит token bucket на каждую пару (маршрут, клиент).
+type rateLimiter struct {
+	cfg     RateLimitConfig
+	key     RateLimitKeyFunc
+	metrics *platformotel.RateLimitMetrics
+
+	mu      sync.Mutex
+	buckets map[rateLimitBucketKey]*tokenBucket
+	sweptAt time.Time
+}
+
+type rateLimitBucketKey struct {
+	route  string
+	client string
+}
+
+type tokenBucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// refill пополняет bucket на время, прошедшее с последнего обращения.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.limit.burst(), b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+	b.last = now
+}
+
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	key := cfg.Key
+	if key == nil {
+		key = RateLimitByIP()
+	}
+	return &rateLimiter{
+		cfg:     cfg,
+		key:     key,
+		buckets: make(map[rateLimitBucketKey]*tokenBucket),
+		sweptAt: time.Now(),
+	}
+}
+
+// allow списывает токен из bucket клиента. Если токенов нет, возвращает время до появления следующего.
+func (l *rateLimiter) allow(ctx context.Context, req RateLimitRequest) (bool, time.Duration) {
+	limit, ok := l.cfg.Routes[req.Route]
+	if !ok {
+		if isSystemMethod(req.Route) {
+			return true, 0
+		}
+		limit = l.cfg.Default
+	}
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+	client := l.key(req)
+	if client == "" {
+		return true, 0
+	}
+
+	now := time.Now()
+	k := rateLimitBucketKey{route: req.Route, client: client}
+
+	l.mu.Lock()
+	l.sweep(now)
+	b, ok := l.buckets[k]
+	if !ok {
+		b = &tokenBucket{limit: limit, tokens: limit.burst(), last: now}
+		l.buckets[k] = b
+	}
+	b.refill(now)
+
+	allowed := b.tokens >= 1
+	var retryAfter time.Duration
+	if allowed {
+		b.tokens--
+	} else {
+		retryAfter = time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if l.metrics != nil {
+		l.metrics.Record(ctx, req.Route, allowed)
+	}
+	return allowed, retryAfter
+}
+
+// sweep удаляет полностью пополнившиеся bucket-ы — они эквивалентны новым. Вызывается под mu.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.sweptAt) < rateLimitSweepInterval {
+		return
+	}
+	l.sweptAt = now
+	for k, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= b.limit.burst() {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// retryAfterSeconds округляет ожидание вверх до целых секунд для заголовка Retry-After.
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(max(1, int(math.Ceil(d.Seconds()))))
+}
+
+func rateLimitedError(retryAfter string) error {
+	return platformerrors.ResourceExhausted("rate limit exceeded").
+		WithReason("RATE_LIMITED").
+		WithMetadata("retry_after", retryAfter)
+}
+
+// gatewayMiddleware ограничивает запросы grpc-gateway по маршруту "METHOD /pattern", отвечает 429.
+func (l *rateLimiter) gatewayMiddleware(next runtime.HandlerFunc) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, pathParams map[string]string) {
+		req := RateLimitRequest{
+			Route:  gatewayRoute(r),
+			IP:     hostOnly(r.RemoteAddr),
+			Header: r.Header,
+		}
+		req.Claims, _ = auth.FromContext(r.Context())
+		if ok, retryAfter := l.allow(r.Context(), req); !ok {
+			secs := retryAfterSeconds(retryAfter)
+			w.Header().Set("Retry-After", secs)
+			platformerrors.WriteProblem(w, platformerrors.NewProblem(r.Context(), w, r, rateLimitedError(secs)))
+			return
+		}
+		next(w, r, pathParams)
+	}
+}
+
+// unaryInterceptor ограничивает gRPC вызовы по полному методу, отвечает RESOURCE_EXHAUSTED.
+// Вызовы gateway через in-process соединение уже ограничены по HTTP маршруту и пропускаются.
+func (l *rateLimiter) unaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := l.checkGRPC(ctx, info.FullMethod, grpc.SetHeader); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamInterceptor — аналог unaryInterceptor для стримов.
+func (l *rateLimiter) streamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		setHeader := func(_ context.Context, md metadata.MD) error { return ss.SetHeader(md) }
+		if err := l.checkGRPC(ss.Context(), info.FullMethod, setHeader); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (l *rateLimiter) checkGRPC(ctx context.Context, method string, setHeader func(context.Context, metadata.MD) error) error {
+	if isInProcess(ctx) {
+		return nil
+	}
+
+	req := RateLimitRequest{Route: method, Header: http.Header{}}
+	req.Claims, _ = auth.FromContext(ctx)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		req.IP = hostOnly(p.Addr.String())
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for k, vs := range md {
+			req.Header[http.CanonicalHeaderKey(k)] = vs
+		}
+	}
+
+	if ok, retryAfter := l.allow(ctx, req); !ok {
+		secs := retryAfterSeconds(retryAfter)
+		_ = setHeader(ctx, metadata.Pairs("retry-after", secs))
+		return platformerrors.ToStatus(rateLimitedError(secs)).Err()
+	}
+	return nil
+}
+
+// hostOnly отрезает порт от адреса "host:port".
+func hostOnly(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// initRateLimit подключает rate limiter к grpc-gateway и gRPC серверу, если задан WithRateLimit.
+// Вызывается после initAuth: ключ клиента может зависеть от проверенных claims.
+func (s *Server) initRateLimit() error {
+	if s.rateLimit == nil {
+		return nil
+	}
+	if err := s.rateLimit.validate(); err != nil {
+		return fmt.Errorf("rate limit config: %w", err)
+	}
+
+	l := newRateLimiter(*s.rateLimit)
+	if s.otelCfg != nil {
+		l.metrics = platformotel.NewRateLimitMetrics(s.otelCfg.ServiceName)
+	}
+
+	s.gatewayMiddleware = append(s.gatewayMiddleware, l.gatewayMiddleware)
+	s.appendInterceptors(serverInterceptors{l.unaryInterceptor(), l.streamInterceptor()})
+	return nil
+}
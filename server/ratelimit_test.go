@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vovanwin/platform/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimitConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     RateLimitConfig
+		wantErr bool
+	}{
+		{"zero", RateLimitConfig{}, false},
+		{"negative default rate", RateLimitConfig{Default: RateLimit{Rate: -1}}, true},
+		{"negative route burst", RateLimitConfig{Routes: map[string]RateLimit{"GET /v1/a": {Rate: 1, Burst: -1}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-Api-Key", "k1")
+	header.Set("Authorization", "Bearer "+forgedToken("attacker"))
+
+	tests := []struct {
+		name string
+		key  RateLimitKeyFunc
+		req  RateLimitRequest
+		want string
+	}{
+		{"ip", RateLimitByIP(), RateLimitRequest{IP: "10.0.0.1"}, "ip:10.0.0.1"},
+		{"header", RateLimitByHeader("X-API-Key"), RateLimitRequest{IP: "10.0.0.1", Header: header}, "header:k1"},
+		{"header missing", RateLimitByHeader("X-Other"), RateLimitRequest{IP: "10.0.0.1", Header: header}, "ip:10.0.0.1"},
+		{"verified subject", RateLimitByJWTSubject(), RateLimitRequest{IP: "10.0.0.1", Claims: claimsFor("user-1")}, "sub:user-1"},
+		{"unverified token", RateLimitByJWTSubject(), RateLimitRequest{IP: "10.0.0.1", Header: header}, "ip:10.0.0.1"},
+		{"empty subject", RateLimitByJWTSubject(), RateLimitRequest{IP: "10.0.0.1", Claims: claimsFor("")}, "ip:10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key(tt.req); got != tt.want {
+				t.Errorf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  RateLimitConfig
+		// reqs — запросы подряд; want — ожидаемый результат каждого
+		reqs []RateLimitRequest
+		want []bool
+	}{
+		{
+			name: "burst then reject",
+			cfg:  RateLimitConfig{Default: RateLimit{Rate: 1, Burst: 2}},
+			reqs: repeat(RateLimitRequest{Route: "GET /v1/a", IP: "10.0.0.1"}, 3),
+			want: []bool{true, true, false},
+		},
+		{
+			name: "route rule overrides default",
+			cfg:  RateLimitConfig{Default: RateLimit{Rate: 100}, Routes: map[string]RateLimit{"POST /v1/a": {Rate: 1}}},
+			reqs: repeat(RateLimitRequest{Route: "POST /v1/a", IP: "10.0.0.1"}, 2),
+			want: []bool{true, false},
+		},
+		{
+			name: "separate buckets per client",
+			cfg:  RateLimitConfig{Default: RateLimit{Rate: 1}},
+			reqs: []RateLimitRequest{{Route: "GET /v1/a", IP: "10.0.0.1"}, {Route: "GET /v1/a", IP: "10.0.0.2"}},
+			want: []bool{true, true},
+		},
+		{
+			name: "separate buckets per route",
+			cfg:  RateLimitConfig{Default: RateLimit{Rate: 1}},
+			reqs: []RateLimitRequest{{Route: "GET /v1/a", IP: "10.0.0.1"}, {Route: "GET /v1/b", IP: "10.0.0.1"}},
+			want: []bool{true, true},
+		},
+		{
+			name: "forged subjects share ip bucket",
+			cfg:  RateLimitConfig{Default: RateLimit{Rate: 1}, Key: RateLimitByJWTSubject()},
+			reqs: []RateLimitRequest{
+				{Route: "GET /v1/a", IP: "10.0.0.1", Header: http.Header{"Authorization": {"Bearer " + forgedToken("a")}}},
+				{Route: "GET /v1/a", IP: "10.0.0.1", Header: http.Header{"Authorization": {"Bearer " + forgedToken("b")}}},
+			},
+			want: []bool{true, false},
+		},
+		{
+			name: "health exempt from default",
+			cfg:  RateLimitConfig{Default: RateLimit{Rate: 1}},
+			reqs: repeat(RateLimitRequest{Route: "/grpc.health.v1.Health/Check", IP: "10.0.0.1"}, 3),
+			want: []bool{true, true, true},
+		},
+		{
+			name: "empty key not limited",
+			cfg:  RateLimitConfig{Default: RateLimit{Rate: 1}, Key: func(RateLimitRequest) string { return "" }},
+			reqs: repeat(RateLimitRequest{Route: "GET /v1/a"}, 3),
+			want: []bool{true, true, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter(tt.cfg)
+			for i, req := range tt.reqs {
+				ok, retryAfter := l.allow(context.Background(), req)
+				if ok != tt.want[i] {
+					t.Fatalf("request %d: allowed = %v, want %v", i, ok, tt.want[i])
+				}
+				if !ok && (retryAfter <= 0 || retryAfter > time.Second) {
+					t.Errorf("request %d: retryAfter = %v", i, retryAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterResponses(t *testing.T) {
+	l := newRateLimiter(RateLimitConfig{Default: RateLimit{Rate: 0.5}})
+	called := 0
+	h := l.gatewayMiddleware(func(http.ResponseWriter, *http.Request, map[string]string) { called++ })
+
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/a", nil), nil)
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/v1/a", nil), nil)
+
+	if called != 1 {
+		t.Errorf("gateway handler called %d times, want 1", called)
+	}
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.9"), Port: 1}})
+	info := &grpc.UnaryServerInfo{FullMethod: "/orders.OrderService/Get"}
+	noop := func(context.Context, any) (any, error) { return nil, nil }
+	if _, err := l.unaryInterceptor()(ctx, nil, info, noop); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if _, err := l.unaryInterceptor()(ctx, nil, info, noop); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second call: code = %v, want ResourceExhausted", status.Code(err))
+	}
+}
+
+func repeat(req RateLimitRequest, n int) []RateLimitRequest {
+	reqs := make([]RateLimitRequest, n)
+	for i := range reqs {
+		reqs[i] = req
+	}
+	return reqs
+}
+
+func claimsFor(sub string) *auth.Claims {
+	c := &auth.Claims{}
+	c.Subject = sub
+	return c
+}
+
+// forgedToken — JWT с произвольным "sub" без действительной подписи.
+func forgedToken(sub string) string {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"none"}`)) + "." + enc([]byte(`{"sub":"`+sub+`"}`)) + ".sig"
+}
@@ -0,0 +1,280 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	platformotel "github.com/vovanwin/platform/otel"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	defaultReadinessTimeout  = 2 * time.Second
+	defaultReadinessCacheTTL = time.Second
+	defaultReadinessInterval = 5 * time.Second
+)
+
+// ReadinessCheck — проверка готовности зависимости (БД, брокер, кэш).
+// Регистрируется через WithReadinessCheck или fx group "readiness_checks".
+type ReadinessCheck struct {
+	// Name имя проверки в ответе /readyz и в метриках.
+	Name string
+	// Check возвращает ошибку, если зависимость недоступна.
+	Check func(ctx context.Context) error
+	// Timeout таймаут одной проверки. 0 — используется Config.ReadinessTimeout.
+	Timeout time.Duration
+}
+
+// checkResult — результат одной проверки в ответе /readyz?verbose.
+type checkResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+	Cached   bool   `json:"cached"`
+}
+
+// readinessEntry хранит проверку и закэшированный результат её последнего запуска.
+type readinessEntry struct {
+	check     ReadinessCheck
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+	elapsed   time.Duration
+	// running закрывается, когда последний вызов Check вернётся. Проверка, которая не следит
+	// за ctx, может пережить свой таймаут — новый вызов не начинается, пока она не вернётся.
+	running chan struct{}
+}
+
+// readiness выполняет зарегистрированные проверки с таймаутами и кэшированием результатов.
+type readiness struct {
+	entries  []*readinessEntry
+	timeout  time.Duration
+	cacheTTL time.Duration
+	metrics  *platformotel.ReadinessMetrics
+
+	mu      sync.RWMutex
+	blocked string        // причина принудительной неготовности (запуск, остановка)
+	changed chan struct{} // сигнал фоновому наблюдателю о смене blocked
+}
+
+func newReadiness(checks []ReadinessCheck, timeout, cacheTTL time.Duration) *readiness {
+	r := &readiness{timeout: timeout, cacheTTL: cacheTTL, changed: make(chan struct{}, 1)}
+	for _, c := range checks {
+		r.entries = append(r.entries, &readinessEntry{check: c})
+	}
+	return r
+}
+
+// block принудительно переводит сервер в состояние «не готов» независимо от проверок.
+func (r *readiness) block(reason string) {
+	r.mu.Lock()
+	r.blocked = reason
+	r.mu.Unlock()
+	r.notify()
+}
+
+// unblock снимает принудительную неготовность.
+func (r *readiness) unblock() {
+	r.block("")
+}
+
+// blockReason возвращает причину принудительной неготовности или пустую строку.
+func (r *readiness) blockReason() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.blocked
+}
+
+func (r *readiness) notify() {
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+}
+
+// run выполняет все проверки параллельно и возвращает результаты и общий статус.
+// При принудительной неготовности (block) сервер не готов независимо от результатов проверок.
+func (r *readiness) run(ctx context.Context) ([]checkResult, bool) {
+	results := make([]checkResult, len(r.entries))
+
+	var wg sync.WaitGroup
+	for i, e := range r.entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.runEntry(ctx, e)
+		}()
+	}
+	wg.Wait()
+
+	ready := r.blockReason() == ""
+	for _, res := range results {
+		if res.Status != "ok" {
+			ready = false
+		}
+	}
+	return results, ready
+}
+
+// runEntry выполняет одну проверку или возвращает закэшированный результат.
+// Одновременные запросы ждут одного и того же запуска проверки, но не дольше её таймаута.
+func (r *readiness) runEntry(ctx context.Context, e *readinessEntry) checkResult {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cached := !e.checkedAt.IsZero() && time.Since(e.checkedAt) < r.cacheTTL
+	if !cached {
+		e.elapsed, e.err = r.execute(ctx, e)
+		e.checkedAt = time.Now()
+
+		if r.metrics != nil {
+			r.metrics.Record(ctx, e.check.Name, e.err, e.elapsed)
+		}
+	}
+
+	res := checkResult{
+		Name:     e.check.Name,
+		Status:   "ok",
+		Duration: e.elapsed.String(),
+		Cached:   cached,
+	}
+	if e.err != nil {
+		res.Status = "fail"
+		res.Error = e.err.Error()
+	}
+	return res
+}
+
+// execute вызывает Check в отдельной горутине и ждёт результата не дольше таймаута проверки,
+// поэтому проверка, не следящая за ctx, не блокирует /readyz. Вызывается под e.mu.
+func (r *readiness) execute(ctx context.Context, e *readinessEntry) (time.Duration, error) {
+	if e.running != nil {
+		select {
+		case <-e.running:
+		default:
+			return 0, errors.New("previous check has not returned yet")
+		}
+	}
+
+	timeout := e.check.Timeout
+	if timeout <= 0 {
+		timeout = r.timeout
+	}
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	running := make(chan struct{})
+	e.running = running
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		defer close(running)
+		done <- safeCheck(checkCtx, e.check.Check)
+	}()
+
+	select {
+	case err := <-done:
+		return time.Since(start), err
+	case <-checkCtx.Done():
+		return time.Since(start), fmt.Errorf("check did not return within %s: %w", timeout, checkCtx.Err())
+	}
+}
+
+// safeCheck вызывает проверку, превращая панику в ошибку.
+func safeCheck(ctx context.Context, check func(ctx context.Context) error) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return check(ctx)
+}
+
+// handler возвращает обработчик /readyz.
+// Без параметров отвечает "ok" (200) или "not ready" (503),
+// с ?verbose — JSON с результатом каждой проверки.
+func (r *readiness) handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		results, ready := r.run(req.Context())
+
+		code := http.StatusOK
+		status := "ok"
+		if !ready {
+			code = http.StatusServiceUnavailable
+			status = "not ready"
+		}
+		reason := r.blockReason()
+
+		if _, verbose := req.URL.Query()["verbose"]; verbose {
+			body := map[string]any{
+				"status": status,
+				"checks": results,
+			}
+			if reason != "" {
+				body["reason"] = reason
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(code)
+			_ = json.NewEncoder(w).Encode(body)
+			return
+		}
+		if reason != "" {
+			status = reason
+		}
+
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(status))
+	}
+}
+
+// startReadinessWatch периодически выполняет readiness-проверки и переключает
+// статус gRPC health-сервиса между SERVING и NOT_SERVING.
+// Смена принудительной неготовности (запуск, остановка) применяется сразу, не дожидаясь тика.
+func (s *Server) startReadinessWatch(log *slog.Logger) {
+	if s.health == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopReadinessWatch = cancel
+
+	go func() {
+		ticker := time.NewTicker(s.cfg.readinessInterval())
+		defer ticker.Stop()
+
+		wasReady := true
+		for {
+			_, ready := s.readiness.run(ctx)
+			if ready != wasReady {
+				if ready {
+					log.Info("Сервер готов, gRPC health: SERVING")
+				} else {
+					log.Warn("Сервер не готов, gRPC health: NOT_SERVING",
+						slog.String("reason", s.readiness.blockReason()),
+					)
+				}
+			}
+			if ready {
+				s.health.SetAllServingStatus(grpc_health_v1.HealthCheckResponse_SERVING)
+			} else {
+				s.health.SetAllServingStatus(grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+			}
+			wasReady = ready
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.readiness.changed:
+			}
+		}
+	}()
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// TestReadinessWatchHealth проверяет, что фоновые readiness-проверки и остановка переключают
+// статус gRPC health, а Watch клиента видит каждый переход.
+func TestReadinessWatchHealth(t *testing.T) {
+	var failing atomic.Bool
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	s := newServer(Config{
+		Host:              "127.0.0.1",
+		GRPCPort:          "0",
+		ReadinessCacheTTL: time.Millisecond,
+		ReadinessInterval: 20 * time.Millisecond,
+	}, WithReadinessCheck("db", func(context.Context) error {
+		if failing.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	}))
+	s.readiness = newReadiness(s.readinessChecks, s.cfg.readinessTimeout(), s.cfg.readinessCacheTTL())
+	s.initStartup()
+	if err := s.initGRPC(log); err != nil {
+		t.Fatalf("initGRPC: %v", err)
+	}
+	t.Cleanup(s.grpcServer.Stop)
+	s.startReadinessWatch(log)
+	t.Cleanup(s.stopReadinessWatch)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := healthpb.NewHealthClient(dial(t, s.addrs.GRPC, insecure.NewCredentials())).
+		Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func()
+		want   healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{"initial", func() {}, healthpb.HealthCheckResponse_SERVING},
+		{"failed check", func() { failing.Store(true) }, healthpb.HealthCheckResponse_NOT_SERVING},
+		{"recovered check", func() { failing.Store(false) }, healthpb.HealthCheckResponse_SERVING},
+		{"shutdown", s.stopHealth, healthpb.HealthCheckResponse_NOT_SERVING},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change()
+			resp, err := stream.Recv()
+			if err != nil {
+				t.Fatalf("Recv: %v", err)
+			}
+			if resp.GetStatus() != tt.want {
+				t.Errorf("status = %v, want %v", resp.GetStatus(), tt.want)
+			}
+		})
+	}
+}
+
+func TestReadinessHandler(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+	fail := func(context.Context) error { return errors.New("connection refused") }
+
+	tests := []struct {
+		name    string
+		checks  []ReadinessCheck
+		blocked string
+		verbose bool
+		// wantStatus и wantBody — код и тело ответа; wantBody в verbose режиме — фрагмент JSON
+		wantStatus int
+		wantBody   string
+	}{
+		{"no checks", nil, "", false, http.StatusOK, "ok"},
+		{"all ok", []ReadinessCheck{{Name: "db", Check: ok}, {Name: "cache", Check: ok}}, "", false, http.StatusOK, "ok"},
+		{"one failed", []ReadinessCheck{{Name: "db", Check: ok}, {Name: "cache", Check: fail}}, "", false, http.StatusServiceUnavailable, "not ready"},
+		{"blocked", []ReadinessCheck{{Name: "db", Check: ok}}, "shutting down", false, http.StatusServiceUnavailable, "shutting down"},
+		{"verbose failed", []ReadinessCheck{{Name: "cache", Check: fail}}, "", true, http.StatusServiceUnavailable,
+			`"name":"cache","status":"fail","error":"connection refused"`},
+		{"verbose blocked", nil, "starting", true, http.StatusServiceUnavailable, `"reason":"starting"`},
+		{"panic", []ReadinessCheck{{Name: "db", Check: func(context.Context) error { panic("nil pool") }}}, "", true,
+			http.StatusServiceUnavailable, `"error":"panic: nil pool"`},
+		{"timeout", []ReadinessCheck{{Name: "db", Timeout: 10 * time.Millisecond, Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}}, "", true, http.StatusServiceUnavailable, `context deadline exceeded"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newReadiness(tt.checks, time.Second, time.Second)
+			if tt.blocked != "" {
+				r.block(tt.blocked)
+			}
+			target := "/readyz"
+			if tt.verbose {
+				target += "?verbose"
+			}
+			rec := httptest.NewRecorder()
+			r.handler()(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			body := rec.Body.String()
+			if tt.verbose && !strings.Contains(body, tt.wantBody) || !tt.verbose && body != tt.wantBody {
+				t.Errorf("body = %s, want %s", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestReadinessCache(t *testing.T) {
+	var calls atomic.Int32
+	r := newReadiness([]ReadinessCheck{{Name: "db", Check: func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}}}, time.Second, 50*time.Millisecond)
+
+	tests := []struct {
+		name       string
+		wait       time.Duration
+		wantCached bool
+		wantCalls  int32
+	}{
+		{"first run", 0, false, 1},
+		{"within ttl", 0, true, 1},
+		{"after ttl", 100 * time.Millisecond, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			time.Sleep(tt.wait)
+			results, ready := r.run(context.Background())
+			if !ready || results[0].Cached != tt.wantCached {
+				t.Errorf("ready = %v, cached = %v, want cached %v", ready, results[0].Cached, tt.wantCached)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("check calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// TestReadinessStuckCheck проверяет, что проверка, не следящая за ctx, не блокирует /readyz
+// дольше своего таймаута и не запускается повторно, пока не вернётся.
+func TestReadinessStuckCheck(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	r := newReadiness([]ReadinessCheck{{Name: "db", Timeout: 20 * time.Millisecond, Check: func(context.Context) error {
+		if calls.Add(1) == 1 {
+			<-release
+		}
+		return nil
+	}}}, time.Second, time.Millisecond)
+	defer close(release)
+
+	tests := []struct {
+		name      string
+		before    func()
+		wantReady bool
+		wantError string
+		wantCalls int32
+	}{
+		{name: "stuck check times out", wantError: "check did not return within 20ms", wantCalls: 1},
+		{name: "not restarted while stuck", wantError: "previous check has not returned yet", wantCalls: 1},
+		{
+			name: "restarted after return",
+			before: func() {
+				release <- struct{}{}
+			},
+			wantReady: true,
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.before != nil {
+				tt.before()
+			}
+			time.Sleep(5 * time.Millisecond) // cacheTTL
+
+			done := make(chan struct{})
+			var (
+				results []checkResult
+				ready   bool
+			)
+			go func() {
+				defer close(done)
+				results, ready = r.run(context.Background())
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("readiness run is blocked by a stuck check")
+			}
+
+			if ready != tt.wantReady || !strings.Contains(results[0].Error, tt.wantError) {
+				t.Errorf("ready = %v, error = %q, want %v, %q", ready, results[0].Error, tt.wantReady, tt.wantError)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("check calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
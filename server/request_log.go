@@ -0,0 +1,283 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// defaultRedactFields — поля, значения которых заменяются при записи тел запросов и ответов.
+// Сравниваются без учёта регистра, "_" и "-" и как подстрока имени: "token" закрывает и accessToken.
+var defaultRedactFields = []string{
+	"password", "passwd", "secret", "token", "access_token", "refresh_token", "id_token",
+	"api_key", "apikey", "authorization", "credential", "client_secret", "credit_card", "card_number", "cvv",
+}
+
+// debugServerLogSkip — пробы и scrape на debug сервере, которые не нужны в access log.
+var debugServerLogSkip = []string{"/healthz", "/readyz", "/startupz", "/metrics"}
+
+var (
+	// jsonKeyRe — ключ JSON объекта вместе с двоеточием; конец значения ищет jsonValueEnd.
+	jsonKeyRe = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"\s*:\s*`)
+	// formFieldRe — пара key=value в form и query.
+	formFieldRe = regexp.MustCompile(`(^|[&?])([^=&]*)=([^&]*)`)
+)
+
+// RequestLogOption настраивает SlogRequestLogger.
+type RequestLogOption func(*requestLogConfig)
+
+type requestLogConfig struct {
+	skip   []string
+	level  func(status int) slog.Level
+	sample float64
+	bodies int
+	redact []string // нормализованные имена полей, см. redactKey
+}
+
+// RequestLogSkip не логирует запросы по пути: точный путь ("/healthz") или префикс со звёздочкой ("/static/*").
+func RequestLogSkip(paths ...string) RequestLogOption {
+	return func(c *requestLogConfig) {
+		c.skip = append(c.skip, paths...)
+	}
+}
+
+// RequestLogLevel задаёт уровень записи по HTTP статусу вместо HTTPStatusLevel.
+func RequestLogLevel(level func(status int) slog.Level) RequestLogOption {
+	return func(c *requestLogConfig) {
+		c.level = level
+	}
+}
+
+// RequestLogSampling логирует только долю rate (0..1] успешных запросов (статус < 400).
+// Ошибки логируются всегда.
+func RequestLogSampling(rate float64) RequestLogOption {
+	return func(c *requestLogConfig) {
+		c.sample = rate
+	}
+}
+
+// RequestLogBodies включает отладочную запись тел запроса и ответа: не больше maxBytes каждого,
+// только текстовые типы (JSON, text/*, form, XML). Значения JSON и form полей, имя которых без учёта
+// регистра, "_" и "-" содержит одно из defaultRedactFields или переданных redact, заменяются
+// на "[REDACTED]". Не для production: тела попадают в логи.
+func RequestLogBodies(maxBytes int, redact ...string) RequestLogOption {
+	return func(c *requestLogConfig) {
+		c.bodies = maxBytes
+		for _, f := range append(slices.Clone(defaultRedactFields), redact...) {
+			if k := redactKey(f); k != "" {
+				c.redact = append(c.redact, k)
+			}
+		}
+	}
+}
+
+// redactKey нормализует имя поля: accessToken, access_token и Access-Token совпадают.
+func redactKey(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
+
+func newRequestLogConfig(opts []RequestLogOption) *requestLogConfig {
+	c := &requestLogConfig{level: HTTPStatusLevel, sample: 1}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// HTTPStatusLevel — уровень записи по умолчанию: Error для 5xx, Warn для 4xx, Info для остальных.
+func HTTPStatusLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func (c *requestLogConfig) skipped(path string) bool {
+	for _, pattern := range c.skip {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// sampled решает, писать ли запись об успешном запросе.
+func (c *requestLogConfig) sampled(status int) bool {
+	return status >= 400 || c.sample >= 1 || rand.Float64() < c.sample
+}
+
+// sensitive сообщает, что значение поля name нужно скрыть.
+func (c *requestLogConfig) sensitive(name string) bool {
+	key := redactKey(name)
+	return slices.ContainsFunc(c.redact, func(f string) bool { return strings.Contains(key, f) })
+}
+
+// redactBody заменяет значения чувствительных полей: в JSON — пары "ключ": значение, в form — key=value,
+// в остальных текстовых телах — и то и другое.
+func (c *requestLogConfig) redactBody(contentType, body string) string {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	if mt != "application/x-www-form-urlencoded" {
+		body = c.redactJSON(body)
+	}
+	if !strings.HasSuffix(mt, "json") {
+		body = c.redactForm(body)
+	}
+	return body
+}
+
+// redactJSON заменяет значение чувствительного ключа целиком, включая объекты и массивы.
+// Строковые значения остальных ключей пропускаются, чтобы текст внутри них не принимался за ключ;
+// в объекты и массивы остальных ключей поиск заходит. Тело может быть обрезано — незакрытое
+// значение заменяется до конца.
+func (c *requestLogConfig) redactJSON(body string) string {
+	var b strings.Builder
+	pos := 0
+	for {
+		loc := jsonKeyRe.FindStringSubmatchIndex(body[pos:])
+		if loc == nil {
+			break
+		}
+		valueStart := pos + loc[1]
+		b.WriteString(body[pos:valueStart])
+		pos = valueStart
+		if c.sensitive(body[pos-loc[1]+loc[2] : pos-loc[1]+loc[3]]) {
+			b.WriteString(`"[REDACTED]"`)
+			pos = jsonValueEnd(body, valueStart)
+		} else if strings.HasPrefix(body[valueStart:], `"`) {
+			pos = jsonValueEnd(body, valueStart)
+			b.WriteString(body[valueStart:pos])
+		}
+	}
+	b.WriteString(body[pos:])
+	return b.String()
+}
+
+// jsonValueEnd возвращает индекс за концом JSON значения, начинающегося в body[start]:
+// строки с учётом экранирования, объекта или массива с учётом вложенности, числа или литерала.
+// Для значения, обрезанного концом тела, — len(body).
+func jsonValueEnd(body string, start int) int {
+	depth := 0
+	inString := false
+	for i := start; i < len(body); i++ {
+		ch := body[i]
+		switch {
+		case inString:
+			switch ch {
+			case '\\':
+				i++
+			case '"':
+				inString = false
+				if depth == 0 {
+					return i + 1
+				}
+			}
+		case ch == '"':
+			inString = true
+		case ch == '{' || ch == '[':
+			depth++
+		case ch == '}' || ch == ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case depth == 0 && (ch == ',' || ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'):
+			return i
+		}
+	}
+	return len(body)
+}
+
+func (c *requestLogConfig) redactForm(body string) string {
+	return formFieldRe.ReplaceAllStringFunc(body, func(m string) string {
+		sm := formFieldRe.FindStringSubmatch(m)
+		name, err := url.QueryUnescape(sm[2])
+		if err != nil {
+			name = sm[2]
+		}
+		if !c.sensitive(name) {
+			return m
+		}
+		return sm[1] + sm[2] + "=[REDACTED]"
+	})
+}
+
+// bodyAttr возвращает обрезанное и очищенное тело для лога или false для нетекстовых типов.
+func (c *requestLogConfig) bodyAttr(key, contentType string, body []byte, truncated bool) (slog.Attr, bool) {
+	if len(body) == 0 || !isTextContent(contentType) {
+		return slog.Attr{}, false
+	}
+	s := c.redactBody(contentType, string(body))
+	if truncated {
+		s += "…"
+	}
+	return slog.String(key, s), true
+}
+
+func isTextContent(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mt, "text/") ||
+		strings.HasSuffix(mt, "json") ||
+		strings.HasSuffix(mt, "xml") ||
+		mt == "application/x-www-form-urlencoded"
+}
+
+// capture хранит первые limit байт потока.
+type capture struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *capture) write(p []byte) {
+	if rest := c.limit - c.buf.Len(); rest > 0 {
+		c.buf.Write(p[:min(rest, len(p))])
+		if len(p) > rest {
+			c.truncated = true
+		}
+	} else if len(p) > 0 {
+		c.truncated = true
+	}
+}
+
+// countingBody считает прочитанные байты тела запроса и при включённой записи тел сохраняет начало.
+type countingBody struct {
+	io.ReadCloser
+	n       int64
+	capture *capture
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if b.capture != nil {
+		b.capture.write(p[:n])
+	}
+	return n, err
+}
+
+// clientIP — адрес клиента из RemoteAddr без порта. За прокси подключите middleware,
+// переписывающий RemoteAddr из X-Forwarded-For, до SlogRequestLogger.
+func clientIP(r *http.Request) string {
+	return hostOnly(r.RemoteAddr)
+}
@@ -0,0 +1,93 @@
+package server
+
+import "testing"
+
+func TestRequestLogBodyRedaction(t *testing.T) {
+	const (
+		jsonType = "application/json"
+		formType = "application/x-www-form-urlencoded"
+	)
+
+	tests := []struct {
+		name        string
+		redact      []string
+		contentType string
+		body        string
+		truncated   bool
+		want        string
+	}{
+		{"json string", nil, jsonType, `{"login":"bob","password":"hunter2"}`, false,
+			`{"login":"bob","password":"[REDACTED]"}`},
+		{"json camelCase", nil, jsonType, `{"accessToken": "abc", "refreshToken":"def"}`, false,
+			`{"accessToken": "[REDACTED]", "refreshToken":"[REDACTED]"}`},
+		{"json kebab and upper case", nil, jsonType, `{"Client-Secret":"s","API_KEY":"k"}`, false,
+			`{"Client-Secret":"[REDACTED]","API_KEY":"[REDACTED]"}`},
+		{"json number", nil, jsonType, `{"cardNumber":4111111111111111,"cvv":123,"amount":10}`, false,
+			`{"cardNumber":"[REDACTED]","cvv":"[REDACTED]","amount":10}`},
+		{"json literal", nil, jsonType, `{"token":null,"ok":true}`, false,
+			`{"token":"[REDACTED]","ok":true}`},
+		{"json escaped quotes", nil, jsonType, `{"password":"a\"b","note":"c"}`, false,
+			`{"password":"[REDACTED]","note":"c"}`},
+		{"json nested", nil, jsonType, `{"auth":{"idToken":"x"},"tokens":["a"]}`, false,
+			`{"auth":{"idToken":"[REDACTED]"},"tokens":"[REDACTED]"}`},
+		{"json array under sensitive key", nil, jsonType, `{"apiKeys":["k1","k2"],"n":1}`, false,
+			`{"apiKeys":"[REDACTED]","n":1}`},
+		{"json object under sensitive key", nil, jsonType, `{"credentials":{"user":"u","pass":"p]}"},"n":1}`, false,
+			`{"credentials":"[REDACTED]","n":1}`},
+		{"json nested arrays", nil, jsonType, `{"tokens": [["a"], {"b": "c"}] , "n":1}`, false,
+			`{"tokens": "[REDACTED]" , "n":1}`},
+		{"json truncated object", nil, jsonType, `{"user":"bob","credentials":{"pass":"hun`, true,
+			`{"user":"bob","credentials":"[REDACTED]"…`},
+		{"json value mentions field", nil, jsonType, `{"note":"password: x"}`, false,
+			`{"note":"password: x"}`},
+		{"json truncated", nil, jsonType, `{"user":"bob","password":"hun`, true,
+			`{"user":"bob","password":"[REDACTED]"…`},
+		{"custom field", []string{"ssn"}, jsonType, `{"userSSN":"123-45-6789"}`, false,
+			`{"userSSN":"[REDACTED]"}`},
+		{"form", nil, formType, `user=bob&password=hunter2&access_token=abc`, false,
+			`user=bob&password=[REDACTED]&access_token=[REDACTED]`},
+		{"form camelCase", nil, formType, `accessToken=abc&x=1`, false,
+			`accessToken=[REDACTED]&x=1`},
+		{"form escaped key", nil, formType, `client%5Fsecret=abc`, false,
+			`client%5Fsecret=[REDACTED]`},
+		{"json body not parsed as form", nil, jsonType, `{"q":"token=abc"}`, false,
+			`{"q":"token=abc"}`},
+		{"plain text", nil, "text/plain", `refresh_token=abc`, false,
+			`refresh_token=[REDACTED]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newRequestLogConfig([]RequestLogOption{RequestLogBodies(1024, tt.redact...)})
+			attr, ok := c.bodyAttr("request_body", tt.contentType, []byte(tt.body), tt.truncated)
+			if !ok {
+				t.Fatal("body not logged")
+			}
+			if got := attr.Value.String(); got != tt.want {
+				t.Errorf("body = %s\nwant   %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestLogBodyContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json", true},
+		{"application/problem+json; charset=utf-8", true},
+		{"text/plain", true},
+		{"application/x-www-form-urlencoded", true},
+		{"application/xml", true},
+		{"application/octet-stream", false},
+		{"image/png", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if got := isTextContent(tt.contentType); got != tt.want {
+				t.Errorf("isTextContent(%q) = %v, want %v", tt.contentType, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,11 +2,18 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io/fs"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/vovanwin/platform/auth"
 	platformotel "github.com/vovanwin/platform/otel"
+	"github.com/vovanwin/platform/server/grpc/health"
 	"google.golang.org/grpc"
 )
 
@@ -19,6 +26,151 @@ type Config struct {
 	DebugPort   string
 	SwaggerFS   fs.FS // встроенная FS с файлами *.swagger.json
 	ProtoFS     fs.FS // встроенная FS с файлами *.proto
+
+	// SinglePort — gRPC и HTTP gateway на одном listener HTTPPort (GRPCPort и GRPCTLS не используются).
+	// Запросы с Content-Type application/grpc по HTTP/2 уходят в gRPC, остальные — в gateway.
+	// Без HTTPTLS используется h2c (HTTP/2 без TLS).
+	SinglePort bool
+	// SinglePortSwagger — в single-port режиме отдавать Swagger UI на HTTPPort под /swagger/ (SwaggerPort не используется).
+	SinglePortSwagger bool
+
+	// GRPCTLS, HTTPTLS, DebugTLS — TLS/mTLS для соответствующего listener. nil — plaintext.
+	GRPCTLS  *TLSConfig
+	HTTPTLS  *TLSConfig
+	DebugTLS *TLSConfig
+
+	// ReadinessTimeout таймаут одной readiness-проверки по умолчанию. 0 — 2s.
+	ReadinessTimeout time.Duration
+	// ReadinessCacheTTL время жизни результата readiness-проверки. 0 — 1s.
+	ReadinessCacheTTL time.Duration
+	// ReadinessInterval период фоновых readiness-проверок, управляющих статусом gRPC health. 0 — 5s.
+	ReadinessInterval time.Duration
+	// StartupTimeout максимальное время ожидания StartupGate. По истечении fx приложение
+	// останавливается с ошибкой. 0 — без ограничения.
+	StartupTimeout time.Duration
+
+	// ReadHeaderTimeout — время на чтение заголовков HTTP запроса (защита от slowloris). 0 — 10s.
+	// Таймауты и MaxHeaderBytes применяются к HTTP gateway, Swagger и debug серверам;
+	// отрицательное значение таймаута — без ограничения.
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout — время на чтение всего запроса вместе с телом. 0 — 1m.
+	// Debug сервер его не использует: pprof профили пишутся дольше.
+	ReadTimeout time.Duration
+	// WriteTimeout — время от конца чтения заголовков до конца записи ответа. 0 — 1m.
+	// ReadTimeout и WriteTimeout снимаются с маршрутов gateway streaming методов, с gRPC вызовов
+	// в SinglePort режиме и не используются debug сервером. Обработчик долгой выгрузки
+	// (WithGatewayHandlePath) снимает дедлайн сам через http.NewResponseController.
+	WriteTimeout time.Duration
+	// IdleTimeout — время жизни keep-alive соединения без запросов. 0 — 2m.
+	IdleTimeout time.Duration
+	// MaxHeaderBytes — максимальный размер заголовков HTTP запроса. 0 — 1MB.
+	MaxHeaderBytes int
+	// MaxRequestBodyBytes — максимальный размер тела запроса HTTP gateway (413 при превышении)
+	// и входящего gRPC сообщения (MaxRecvMsgSize). 0 — 4MB, отрицательное — без ограничения.
+	// Для отдельных маршрутов переопределяется WithRequestBodyLimit.
+	MaxRequestBodyBytes int64
+	// MaxResponseBodyBytes — максимальный размер исходящего gRPC сообщения (MaxSendMsgSize),
+	// в том числе ответа, который gateway получает по in-process соединению. 0 — без ограничения.
+	MaxResponseBodyBytes int64
+
+	// GRPCKeepalive — keepalive, возраст соединений (GOAWAY для перебалансировки клиентов)
+	// и лимит стримов gRPC сервера. Нулевое значение — безопасные значения по умолчанию.
+	GRPCKeepalive GRPCKeepaliveConfig
+
+	// PreStopDelay пауза между переводом в not-ready и остановкой серверов,
+	// чтобы балансировщики успели убрать под из ротации. 0 — без паузы.
+	PreStopDelay time.Duration
+	// ShutdownTimeout дедлайн на graceful drain HTTP и gRPC, после него — принудительная остановка. 0 — 30s.
+	// fx.StopTimeout должен быть больше PreStopDelay + ShutdownTimeout.
+	ShutdownTimeout time.Duration
+}
+
+// Validate проверяет конфигурацию до запуска серверов: порты заданы, корректны и не пересекаются.
+// Порт "0" означает случайный свободный порт и может повторяться.
+func (c Config) Validate() error {
+	ports := []struct {
+		name  string
+		value string
+	}{
+		{"HTTPPort", c.HTTPPort},
+		{"DebugPort", c.DebugPort},
+	}
+	if !c.SinglePort {
+		ports = append(ports, struct {
+			name  string
+			value string
+		}{"GRPCPort", c.GRPCPort})
+	}
+	if c.SwaggerFS != nil && !(c.SinglePort && c.SinglePortSwagger) {
+		ports = append(ports, struct {
+			name  string
+			value string
+		}{"SwaggerPort", c.SwaggerPort})
+	}
+
+	var errs []error
+	seen := make(map[string]string)
+	for _, p := range ports {
+		if p.value == "" {
+			errs = append(errs, fmt.Errorf("%s is empty", p.name))
+			continue
+		}
+		n, err := strconv.Atoi(p.value)
+		if err != nil || n < 0 || n > 65535 {
+			errs = append(errs, fmt.Errorf("%s: invalid port %q", p.name, p.value))
+			continue
+		}
+		if n == 0 {
+			continue
+		}
+		if other, ok := seen[p.value]; ok {
+			errs = append(errs, fmt.Errorf("%s collides with %s on port %s", p.name, other, p.value))
+			continue
+		}
+		seen[p.value] = p.name
+	}
+
+	for name, t := range map[string]*TLSConfig{"GRPCTLS": c.GRPCTLS, "HTTPTLS": c.HTTPTLS, "DebugTLS": c.DebugTLS} {
+		if t == nil {
+			continue
+		}
+		if err := t.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid server config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (c Config) readinessTimeout() time.Duration {
+	if c.ReadinessTimeout <= 0 {
+		return defaultReadinessTimeout
+	}
+	return c.ReadinessTimeout
+}
+
+func (c Config) readinessCacheTTL() time.Duration {
+	if c.ReadinessCacheTTL <= 0 {
+		return defaultReadinessCacheTTL
+	}
+	return c.ReadinessCacheTTL
+}
+
+func (c Config) shutdownTimeout() time.Duration {
+	if c.ShutdownTimeout <= 0 {
+		return defaultShutdownTimeout
+	}
+	return c.ShutdownTimeout
+}
+
+func (c Config) readinessInterval() time.Duration {
+	if c.ReadinessInterval <= 0 {
+		return defaultReadinessInterval
+	}
+	return c.ReadinessInterval
 }
 
 // GRPCRegistrator — колбэк для регистрации gRPC сервисов.
@@ -27,6 +179,19 @@ type GRPCRegistrator func(s *grpc.Server)
 // GatewayRegistrator — колбэк для регистрации grpc-gateway in-process.
 type GatewayRegistrator func(ctx context.Context, mux *runtime.ServeMux, server *grpc.Server) error
 
+// GatewayConnRegistrator — колбэк для регистрации grpc-gateway через клиентское соединение
+// к собственному gRPC серверу (RegisterXHandler вместо RegisterXHandlerServer).
+// Соединение in-process (bufconn), поэтому REST запросы проходят через все gRPC interceptors:
+// auth, валидацию, per-method метрики.
+type GatewayConnRegistrator func(ctx context.Context, mux *runtime.ServeMux, conn *grpc.ClientConn) error
+
+// gatewayPath — маршрут WithGatewayHandlePath.
+type gatewayPath struct {
+	method  string
+	pattern string
+	handler runtime.HandlerFunc
+}
+
 // Option — функциональные опции для Server.
 type Option func(*Server)
 
@@ -36,26 +201,81 @@ type DebugHandler struct {
 	Handler http.Handler
 }
 
+// Addrs — фактические адреса, на которых слушают серверы после запуска.
+// При порте "0" содержат выбранный ОС порт. Пустая строка — сервер не запущен.
+type Addrs struct {
+	GRPC    string
+	HTTP    string
+	Swagger string
+	Debug   string
+}
+
 // Server объединяет все 4 сервера: gRPC, HTTP gateway, Swagger, Debug.
+// Доступен в fx контейнере как *server.Server.
 type Server struct {
 	cfg Config
 
 	grpcRegistrators    []GRPCRegistrator
 	gatewayRegistrators []GatewayRegistrator
+	gatewayConnRegs     []GatewayConnRegistrator
+	gatewayPaths        []gatewayPath
+	gatewayMuxOpts      []runtime.ServeMuxOption
+	gatewayMiddleware   []runtime.Middleware
 	httpMiddleware      []func(http.Handler) http.Handler
 	debugMiddleware     []func(http.Handler) http.Handler
 	debugHandlers       []DebugHandler
-	grpcOptions         []grpc.ServerOption
+	grpcOptions         []grpc.ServerOption // платформенные опции, собираются init* функциями
+	userGRPCOptions     []grpc.ServerOption // WithGRPCOptions, после платформенных interceptors
+	readinessChecks     []ReadinessCheck
+	startupGates        []*StartupGate
+	shutdownHooks       []ShutdownHook
+	logFlush            []LogFlush
+
+	// Имена звеньев gRPC цепочки в порядке grpcOptions — для /debug/routes (см. appendInterceptors)
+	unaryChain  []string
+	streamChain []string
+	statsChain  []string
 
 	otelCfg      *platformotel.Config
 	otelProvider *platformotel.Provider
 	httpRoutes   []string // роуты для per-route HTTP метрик
 	grpcMethods  []string // методы для per-method gRPC метрик
 
+	rateLimit        *RateLimitConfig
+	concurrencyLimit *ConcurrencyLimitConfig
+	concurrency      *concurrencyLimiter
+	authCfg          *auth.Config
+	auth             *auth.Authenticator
+	policyCfg        *auth.PolicyConfig
+	corsCfg          *CORSConfig
+	swaggerCORS      bool
+	cors             *cors
+	bodyLimits       map[string]int64 // "METHOD /pattern" → лимит тела запроса
+	grpcLogOpts      []GRPCLogOption
+	requestLogOpts   []RequestLogOption
+	debugLogCfg      *platformotel.DebugLogConfig
+	debugLog         *platformotel.DebugLog
+	userDebugConfigs []DebugConfig
+
 	grpcServer *grpc.Server
 	httpServer *http.Server
 	swaggerSrv *http.Server
 	debugSrv   *http.Server
+	addrs      Addrs
+
+	httpRouter  *chi.Mux // для /debug/routes
+	debugRouter *chi.Mux
+
+	inProcessConn *grpc.ClientConn
+	gatewayRoutes map[string]string // "METHOD /pattern" → полный gRPC метод (см. gatewayRouteMethods)
+	// gatewayStreams — маршруты gateway streaming методов: без таймаутов и лимита конкурентности
+	gatewayStreams map[string]struct{}
+
+	health    *health.Server
+	readiness *readiness
+	startup   *startup
+
+	stopReadinessWatch context.CancelFunc
 }
 
 // WithGRPCRegistrator добавляет колбэк для регистрации gRPC сервисов.
@@ -72,6 +292,32 @@ func WithGatewayRegistrator(r GatewayRegistrator) Option {
 	}
 }
 
+// WithGatewayConnRegistrator добавляет колбэк для регистрации grpc-gateway хендлеров
+// поверх in-process gRPC соединения. REST запросы этих хендлеров проходят через gRPC interceptors.
+func WithGatewayConnRegistrator(r GatewayConnRegistrator) Option {
+	return func(s *Server) {
+		s.gatewayConnRegs = append(s.gatewayConnRegs, r)
+	}
+}
+
+// WithGatewayHandlePath добавляет в grpc-gateway ServeMux маршрут без proto сервиса (скачивание файлов,
+// вебхуки) через ServeMux.HandlePath. В отличие от вызова HandlePath из регистратора маршрут
+// попадает в /debug/routes. Регистрируется после маршрутов gateway регистраторов.
+func WithGatewayHandlePath(method, pattern string, h runtime.HandlerFunc) Option {
+	return func(s *Server) {
+		s.gatewayPaths = append(s.gatewayPaths, gatewayPath{method: method, pattern: pattern, handler: h})
+	}
+}
+
+// WithGatewayMuxOptions добавляет опции grpc-gateway ServeMux: маршалинг JSON, matchers заголовков,
+// metadata annotators, обработчики ошибок. Применяются после платформенных опций по умолчанию
+// и переопределяют их (напр. свой WithIncomingHeaderMatcher заменяет GatewayHeaderMatcher).
+func WithGatewayMuxOptions(opts ...runtime.ServeMuxOption) Option {
+	return func(s *Server) {
+		s.gatewayMuxOpts = append(s.gatewayMuxOpts, opts...)
+	}
+}
+
 // WithHTTPMiddleware добавляет middleware на HTTP gateway.
 func WithHTTPMiddleware(mw ...func(http.Handler) http.Handler) Option {
 	return func(s *Server) {
@@ -93,10 +339,59 @@ func WithDebugHandler(pattern string, handler http.Handler) Option {
 	}
 }
 
-// WithGRPCOptions добавляет опции для gRPC сервера.
+// WithReadinessCheck регистрирует проверку зависимости для /readyz.
+// Проверка выполняется с таймаутом Config.ReadinessTimeout, результат кэшируется на Config.ReadinessCacheTTL.
+func WithReadinessCheck(name string, check func(ctx context.Context) error) Option {
+	return func(s *Server) {
+		s.readinessChecks = append(s.readinessChecks, ReadinessCheck{Name: name, Check: check})
+	}
+}
+
+// WithStartupGate регистрирует gate, который удерживает сервер в состоянии запуска до вызова Release.
+func WithStartupGate(gates ...*StartupGate) Option {
+	return func(s *Server) {
+		s.startupGates = append(s.startupGates, gates...)
+	}
+}
+
+// WithShutdownHook регистрирует действие, выполняемое в заданной фазе остановки сервера.
+func WithShutdownHook(phase ShutdownPhase, name string, fn func(ctx context.Context) error) Option {
+	return func(s *Server) {
+		s.shutdownHooks = append(s.shutdownHooks, ShutdownHook{Name: name, Phase: phase, Fn: fn})
+	}
+}
+
+// WithLogFlush регистрирует сброс буфера логов, выполняемый последним шагом остановки — после
+// логов всех фаз (напр. closer из logger.NewLogger для Loki). Ограничен дедлайном фазы flush.
+func WithLogFlush(flush func()) Option {
+	return func(s *Server) {
+		s.logFlush = append(s.logFlush, flush)
+	}
+}
+
+// WithRequestLogOptions настраивает access log HTTP gateway и debug сервера (SlogRequestLogger):
+// уровни по статусу, пропуск путей, сэмплирование, запись тел запросов в отладке.
+// На debug сервере /healthz, /readyz, /startupz и /metrics не логируются всегда.
+func WithRequestLogOptions(opts ...RequestLogOption) Option {
+	return func(s *Server) {
+		s.requestLogOpts = append(s.requestLogOpts, opts...)
+	}
+}
+
+// WithGRPCLogOptions настраивает access log gRPC вызовов (SlogUnaryInterceptor и SlogStreamInterceptor):
+// пропуск методов, уровень по коду ответа, логирование вызовов gateway.
+func WithGRPCLogOptions(opts ...GRPCLogOption) Option {
+	return func(s *Server) {
+		s.grpcLogOpts = append(s.grpcLogOpts, opts...)
+	}
+}
+
+// WithGRPCOptions добавляет опции для gRPC сервера. Они применяются после платформенных и могут
+// их переопределить (напр. MaxRecvMsgSize, keepalive). Interceptors выполняются после аутентификации,
+// авторизации и лимитов — auth.FromContext в них уже доступен, — но до перевода доменных ошибок в статусы.
 func WithGRPCOptions(opts ...grpc.ServerOption) Option {
 	return func(s *Server) {
-		s.grpcOptions = append(s.grpcOptions, opts...)
+		s.userGRPCOptions = append(s.userGRPCOptions, opts...)
 	}
 }
 
@@ -104,6 +399,7 @@ func WithGRPCOptions(opts ...grpc.ServerOption) Option {
 // При включении автоматически добавляются:
 //   - трейсы и метрики (TracerProvider + MeterProvider)
 //   - RecoveryMiddleware (panic recovery с записью в спан)
+//   - RecoveryUnaryInterceptor и RecoveryStreamInterceptor (panic recovery gRPC → INTERNAL)
 //   - MetricsMiddleware (per-route HTTP метрики)
 //   - HTTPMiddleware (OTEL трейсинг HTTP)
 //   - otelgrpc StatsHandler (OTEL трейсинг gRPC)
@@ -135,6 +431,94 @@ func WithGRPCMethodMetrics(methods ...string) Option {
 	}
 }
 
+// WithRateLimit включает rate limiting по token bucket: отдельный bucket на каждую пару
+// (маршрут, клиент). HTTP gateway отвечает 429, gRPC — RESOURCE_EXHAUSTED, оба с Retry-After.
+// При WithOtel экспортируются счётчики {app}.ratelimit.allowed.total и {app}.ratelimit.rejected.total.
+func WithRateLimit(cfg RateLimitConfig) Option {
+	return func(s *Server) {
+		s.rateLimit = &cfg
+	}
+}
+
+// WithConcurrencyLimit включает адаптивное ограничение одновременных запросов, общее для
+// HTTP gateway и gRPC. При перегрузке HTTP отвечает 503, gRPC — UNAVAILABLE; health и reflection
+// не ограничиваются, запросы из ConcurrencyLimitConfig.Critical отбрасываются последними.
+func WithConcurrencyLimit(cfg ConcurrencyLimitConfig) Option {
+	return func(s *Server) {
+		s.concurrencyLimit = &cfg
+	}
+}
+
+// WithAuth включает аутентификацию по JWT для gRPC и HTTP gateway (см. пакет auth).
+// gRPC вызовы проверяются interceptors, REST — middleware grpc-gateway с учётом PublicPaths.
+// REST через in-process соединение (WithGatewayConnRegistrator) проверяется один раз, в HTTP
+// middleware; gRPC interceptor лишь кладёт Claims действительного токена в context обработчика.
+func WithAuth(cfg auth.Config) Option {
+	return func(s *Server) {
+		s.authCfg = &cfg
+	}
+}
+
+// WithAuthPolicy включает авторизацию по полному gRPC методу (см. auth.PolicyConfig).
+// Требует WithAuth. Применяется к gRPC и к REST: при WithGatewayConnRegistrator — через
+// gRPC interceptors, при WithGatewayRegistrator — по аннотациям google.api.http.
+func WithAuthPolicy(cfg auth.PolicyConfig) Option {
+	return func(s *Server) {
+		s.policyCfg = &cfg
+	}
+}
+
+// WithRequestBodyLimit задаёт лимит тела запроса для маршрута grpc-gateway "METHOD /pattern"
+// (напр. "POST /v1/files") вместо Config.MaxRequestBodyBytes. bytes < 0 — без ограничения.
+// gRPC MaxRecvMsgSize увеличивается до наибольшего из лимитов.
+func WithRequestBodyLimit(route string, bytes int64) Option {
+	return func(s *Server) {
+		if s.bodyLimits == nil {
+			s.bodyLimits = make(map[string]int64)
+		}
+		s.bodyLimits[route] = bytes
+	}
+}
+
+// WithCORS включает CORS для HTTP gateway: preflight запросы обрабатываются до grpc-gateway mux,
+// auth и rate limiting, к остальным ответам добавляются Access-Control-* заголовки.
+func WithCORS(cfg CORSConfig) Option {
+	return func(s *Server) {
+		s.corsCfg = &cfg
+	}
+}
+
+// WithSwaggerCORS разрешает запросы к HTTP gateway из Swagger UI на SwaggerPort, чтобы
+// «Try it out» работал против gateway. Совместима с WithCORS (добавляет CORSConfig.AllowSwaggerOrigin).
+func WithSwaggerCORS() Option {
+	return func(s *Server) {
+		s.swaggerCORS = true
+	}
+}
+
+// WithDebugLog включает отладочное логирование отдельных запросов: HTTP запрос с действительным
+// токеном в заголовке X-Debug-Log (gRPC — в metadata x-debug-log) логируется с уровнем DEBUG
+// во всех обработчиках, его трейс сохраняется независимо от SampleRate, а токен уходит
+// в исходящие вызовы через baggage. Уровень по запросу учитывает логгер из logger.NewLogger.
+func WithDebugLog(cfg platformotel.DebugLogConfig) Option {
+	return func(s *Server) {
+		s.debugLogCfg = &cfg
+	}
+}
+
+// WithDebugConfig добавляет конфигурацию в /debug/config на debug сервере под именем name.
+// Поля с тегом secret:"true" отображаются как "[REDACTED]".
+func WithDebugConfig(name string, value any) Option {
+	return func(s *Server) {
+		s.userDebugConfigs = append(s.userDebugConfigs, DebugConfig{Name: name, Value: value})
+	}
+}
+
+// Addrs возвращает фактические адреса серверов. Заполняется в OnStart.
+func (s *Server) Addrs() Addrs {
+	return s.addrs
+}
+
 func newServer(cfg Config, opts ...Option) *Server {
 	s := &Server{cfg: cfg}
 	for _, opt := range opts {
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{GRPCPort: "50051", HTTPPort: "8080", DebugPort: "8081"}
+	swagger := fstest.MapFS{"swagger.json": {Data: []byte("{}")}}
+
+	tests := []struct {
+		name string
+		cfg  func(c *Config)
+		// wantErr — фрагменты текста ошибки; пусто — конфигурация корректна
+		wantErr []string
+	}{
+		{name: "valid", cfg: func(*Config) {}},
+		{name: "random ports", cfg: func(c *Config) { c.GRPCPort, c.HTTPPort, c.DebugPort = "0", "0", "0" }},
+		{name: "empty port", cfg: func(c *Config) { c.HTTPPort = "" }, wantErr: []string{"HTTPPort is empty"}},
+		{name: "not a number", cfg: func(c *Config) { c.DebugPort = "http" }, wantErr: []string{`DebugPort: invalid port "http"`}},
+		{name: "negative port", cfg: func(c *Config) { c.GRPCPort = "-1" }, wantErr: []string{`GRPCPort: invalid port "-1"`}},
+		{name: "port out of range", cfg: func(c *Config) { c.GRPCPort = "65536" }, wantErr: []string{`GRPCPort: invalid port "65536"`}},
+		{name: "collision", cfg: func(c *Config) { c.DebugPort = "8080" }, wantErr: []string{"DebugPort collides with HTTPPort on port 8080"}},
+		{name: "single port ignores grpc port", cfg: func(c *Config) { c.SinglePort, c.GRPCPort = true, "" }},
+		{name: "swagger port required", cfg: func(c *Config) { c.SwaggerFS = swagger }, wantErr: []string{"SwaggerPort is empty"}},
+		{
+			name: "single port swagger ignores swagger port",
+			cfg:  func(c *Config) { c.SwaggerFS, c.SinglePort, c.SinglePortSwagger = swagger, true, true },
+		},
+		{
+			name:    "tls without files",
+			cfg:     func(c *Config) { c.HTTPTLS = &TLSConfig{CertFile: "tls.crt"} },
+			wantErr: []string{"HTTPTLS: CertFile and KeyFile are required"},
+		},
+		{
+			name: "all errors reported",
+			cfg:  func(c *Config) { c.HTTPPort, c.DebugPort, c.GRPCTLS = "", "x", &TLSConfig{} },
+			wantErr: []string{
+				"HTTPPort is empty",
+				`DebugPort: invalid port "x"`,
+				"GRPCTLS: CertFile and KeyFile are required",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.cfg(&cfg)
+			err := cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Validate() = nil, want error")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+// TestModuleRandomPorts проверяет, что при порте "0" Addrs возвращает адреса, на которых
+// серверы действительно слушают.
+func TestModuleRandomPorts(t *testing.T) {
+	var s *Server
+	app := fxtest.New(t,
+		fx.Supply(Config{Host: "127.0.0.1", GRPCPort: "0", HTTPPort: "0", DebugPort: "0"}),
+		fx.Provide(func() *slog.Logger { return slog.New(slog.NewTextHandler(io.Discard, nil)) }),
+		NewModule(),
+		fx.Populate(&s),
+	)
+	app.RequireStart()
+	defer app.RequireStop()
+
+	addrs := s.Addrs()
+	tests := []struct {
+		name string
+		addr string
+		// path — HTTP путь для проверки; пусто — только TCP соединение
+		path string
+	}{
+		{"grpc", addrs.GRPC, ""},
+		{"http", addrs.HTTP, "/"},
+		{"debug", addrs.Debug, "/healthz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, port, err := net.SplitHostPort(tt.addr)
+			if err != nil || port == "0" {
+				t.Fatalf("addr = %q, want a bound port", tt.addr)
+			}
+			if tt.path == "" {
+				conn, err := (&net.Dialer{}).DialContext(context.Background(), "tcp", tt.addr)
+				if err != nil {
+					t.Fatalf("dial %s: %v", tt.addr, err)
+				}
+				_ = conn.Close()
+				return
+			}
+			resp, err := http.Get("http://" + tt.addr + tt.path)
+			if err != nil {
+				t.Fatalf("GET %s: %v", tt.path, err)
+			}
+			_ = resp.Body.Close()
+		})
+	}
+}
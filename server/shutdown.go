@@ -0,0 +1,223 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultShutdownTimeout      = 30 * time.Second
+	defaultShutdownFlushTimeout = 5 * time.Second
+)
+
+// ShutdownPhase — фаза остановки сервера, в которую встраивается пользовательский hook.
+//
+// Порядок остановки:
+//  1. not-ready — /readyz и gRPC health переходят в NOT_SERVING
+//  2. ShutdownPreStop hooks
+//  3. пауза Config.PreStopDelay, чтобы балансировщики убрали под из ротации
+//  4. drain — HTTP gateway, Swagger и gRPC останавливаются параллельно с дедлайном
+//     Config.ShutdownTimeout, после дедлайна — принудительный Stop()/Close()
+//  5. ShutdownAfterDrain hooks
+//  6. flush — OTEL провайдеры и ShutdownFlush hooks
+//  7. остановка debug-сервера
+//  8. сброс буфера логов (WithLogFlush, напр. Loki) — последним, чтобы в него попали логи всех фаз
+type ShutdownPhase int
+
+const (
+	// ShutdownPreStop — сразу после перевода в not-ready, до паузы для балансировщиков.
+	ShutdownPreStop ShutdownPhase = iota
+	// ShutdownAfterDrain — после остановки HTTP и gRPC: закрытие БД, брокеров, фоновых воркеров.
+	ShutdownAfterDrain
+	// ShutdownFlush — вместе с flush OTEL: сброс буферов телеметрии. Буфер логов сбрасывается
+	// позже, через WithLogFlush.
+	ShutdownFlush
+)
+
+func (p ShutdownPhase) String() string {
+	switch p {
+	case ShutdownPreStop:
+		return "pre-stop"
+	case ShutdownAfterDrain:
+		return "after-drain"
+	case ShutdownFlush:
+		return "flush"
+	default:
+		return fmt.Sprintf("phase(%d)", int(p))
+	}
+}
+
+// LogFlush — сброс буфера логов при остановке, напр. closer из logger.NewLogger (Loki).
+// Задаётся через WithLogFlush или предоставляется в fx контейнер как server.LogFlush.
+type LogFlush func()
+
+// ShutdownHook — пользовательское действие при остановке сервера.
+// Регистрируется через WithShutdownHook или fx group "shutdown_hooks".
+type ShutdownHook struct {
+	// Name имя hook для логов.
+	Name string
+	// Phase фаза, в которой выполняется hook.
+	Phase ShutdownPhase
+	// Fn выполняется с контекстом фазы; ошибка логируется и возвращается в fx.
+	Fn func(ctx context.Context) error
+}
+
+// shutdown выполняет остановку сервера по фазам. Каждая фаза логируется с длительностью,
+// ошибки всех фаз собираются и возвращаются в fx.
+func (s *Server) shutdown(ctx context.Context, log *slog.Logger) error {
+	start := time.Now()
+	var errs []error
+
+	errs = append(errs, runShutdownPhase(log, "not-ready", func() []error {
+		s.stopHealth()
+		return nil
+	})...)
+
+	errs = append(errs, s.runShutdownHooks(ctx, log, ShutdownPreStop)...)
+
+	if delay := s.cfg.PreStopDelay; delay > 0 {
+		errs = append(errs, runShutdownPhase(log, "pre-stop-delay", func() []error {
+			select {
+			case <-time.After(delay):
+				return nil
+			case <-ctx.Done():
+				return []error{fmt.Errorf("pre-stop delay: %w", ctx.Err())}
+			}
+		})...)
+	}
+
+	errs = append(errs, runShutdownPhase(log, "drain", func() []error {
+		drainCtx, cancel := context.WithTimeout(ctx, s.cfg.shutdownTimeout())
+		defer cancel()
+
+		var (
+			mu        sync.Mutex
+			drainErrs []error
+			wg        sync.WaitGroup
+		)
+		for _, stop := range []func(context.Context, *slog.Logger) error{
+			s.stopHTTP,
+			s.stopSwagger,
+			s.stopGRPC,
+		} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := stop(drainCtx, log); err != nil {
+					mu.Lock()
+					drainErrs = append(drainErrs, err)
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		return drainErrs
+	})...)
+
+	errs = append(errs, s.runShutdownHooks(ctx, log, ShutdownAfterDrain)...)
+
+	// Flush выполняется даже если дедлайн drain истёк — иначе теряются последние трейсы и логи
+	flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultShutdownFlushTimeout)
+	defer cancel()
+
+	errs = append(errs, runShutdownPhase(log, "flush-otel", func() []error {
+		if err := s.stopOtel(flushCtx, log); err != nil {
+			return []error{err}
+		}
+		return nil
+	})...)
+
+	errs = append(errs, s.runShutdownHooks(flushCtx, log, ShutdownFlush)...)
+
+	errs = append(errs, runShutdownPhase(log, "debug", func() []error {
+		if err := s.stopDebug(flushCtx, log); err != nil {
+			return []error{err}
+		}
+		return nil
+	})...)
+
+	log.Info("Сервер остановлен", slog.Duration("duration", time.Since(start)))
+
+	// После сброса буфера логов в Loki ничего не попадёт — поэтому он последний и без лога фазы
+	if err := s.flushLogs(flushCtx); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// flushLogs сбрасывает буферы логов WithLogFlush. Ждёт не дольше дедлайна ctx: клиент Loki
+// при недоступном сервере повторяет отправку дольше fx.StopTimeout.
+func (s *Server) flushLogs(ctx context.Context) error {
+	if len(s.logFlush) == 0 {
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, flush := range s.logFlush {
+			flush()
+		}
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("log flush: %w", ctx.Err())
+	}
+}
+
+// runShutdownHooks выполняет пользовательские hooks фазы в порядке регистрации.
+func (s *Server) runShutdownHooks(ctx context.Context, log *slog.Logger, phase ShutdownPhase) []error {
+	var errs []error
+	for _, h := range s.shutdownHooks {
+		if h.Phase != phase {
+			continue
+		}
+		errs = append(errs, runShutdownPhase(log, phase.String()+":"+h.Name, func() []error {
+			if err := h.Fn(ctx); err != nil {
+				return []error{fmt.Errorf("shutdown hook %q: %w", h.Name, err)}
+			}
+			return nil
+		})...)
+	}
+	return errs
+}
+
+// runShutdownPhase выполняет фазу остановки, логируя её длительность и ошибки.
+func runShutdownPhase(log *slog.Logger, name string, fn func() []error) []error {
+	start := time.Now()
+	errs := fn()
+
+	if len(errs) > 0 {
+		log.Error("Фаза остановки завершилась с ошибкой",
+			slog.String("phase", name),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("error", errors.Join(errs...).Error()),
+		)
+		return errs
+	}
+
+	log.Info("Фаза остановки завершена",
+		slog.String("phase", name),
+		slog.Duration("duration", time.Since(start)),
+	)
+	return nil
+}
+
+// shutdownHTTPServer корректно останавливает HTTP сервер, а после дедлайна закрывает
+// оставшиеся соединения принудительно.
+func shutdownHTTPServer(ctx context.Context, srv *http.Server) error {
+	err := srv.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		_ = srv.Close()
+	}
+	return err
+}
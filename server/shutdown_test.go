@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordHandler запоминает сообщения логов.
+type recordHandler struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.msgs = append(h.msgs, r.Message)
+	return nil
+}
+
+func (h *recordHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.msgs)
+}
+
+func TestShutdownFlushesLogsLast(t *testing.T) {
+	h := &recordHandler{}
+	var order []string
+	var logsAtFlush int
+
+	s := newServer(Config{},
+		WithShutdownHook(ShutdownFlush, "telemetry", func(context.Context) error {
+			order = append(order, "flush-hook")
+			return nil
+		}),
+		WithLogFlush(func() {
+			order = append(order, "log-flush")
+			logsAtFlush = h.count()
+		}),
+	)
+	if err := s.shutdown(context.Background(), slog.New(h)); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "flush-hook" || order[1] != "log-flush" {
+		t.Fatalf("order = %v, want [flush-hook log-flush]", order)
+	}
+	if logsAtFlush != h.count() {
+		t.Errorf("%d log records written after log flush: %v", h.count()-logsAtFlush, h.msgs[logsAtFlush:])
+	}
+	if last := h.msgs[len(h.msgs)-1]; last != "Сервер остановлен" {
+		t.Errorf("last log record before flush = %q", last)
+	}
+}
+
+func TestFlushLogsDeadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		flush   func()
+		wantErr error
+	}{
+		{"fast", func() {}, nil},
+		{"stuck", func() { time.Sleep(time.Second) }, context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(Config{}, WithLogFlush(tt.flush))
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			if err := s.flushLogs(ctx); !errors.Is(err, tt.wantErr) {
+				t.Errorf("flushLogs() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StartupGate удерживает сервер в состоянии запуска, пока компонент не сообщит о готовности
+// (прогрев кэшей, миграции и т.п.). Пока открыт хотя бы один gate, HTTP gateway отвечает 503,
+// gRPC — UNAVAILABLE, а /startupz и /readyz — 503.
+// Регистрируется через WithStartupGate или fx group "startup_gates".
+type StartupGate struct {
+	name string
+	once sync.Once
+	done chan struct{}
+}
+
+// NewStartupGate создаёт открытый gate с именем для /startupz и логов.
+func NewStartupGate(name string) *StartupGate {
+	return &StartupGate{name: name, done: make(chan struct{})}
+}
+
+// Name возвращает имя gate.
+func (g *StartupGate) Name() string {
+	return g.name
+}
+
+// Release сообщает, что компонент готов. Повторные вызовы безопасны.
+func (g *StartupGate) Release() {
+	g.once.Do(func() { close(g.done) })
+}
+
+// Released возвращает true, если Release уже был вызван.
+func (g *StartupGate) Released() bool {
+	select {
+	case <-g.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// startup отслеживает зарегистрированные gates и общий статус запуска.
+type startup struct {
+	gates []*StartupGate
+	ready atomic.Bool
+}
+
+func newStartup(gates []*StartupGate) *startup {
+	st := &startup{gates: gates}
+	st.ready.Store(len(gates) == 0)
+	return st
+}
+
+// pending возвращает имена ещё не открытых gates.
+func (st *startup) pending() []string {
+	var names []string
+	for _, g := range st.gates {
+		if !g.Released() {
+			names = append(names, g.name)
+		}
+	}
+	return names
+}
+
+// wait ждёт открытия всех gates в фоне и вызывает onReady. Если timeout > 0 и gates
+// не открылись за это время, вызывается onTimeout со списком незавершённых gates.
+func (st *startup) wait(log *slog.Logger, timeout time.Duration, onReady func(), onTimeout func(pending []string)) {
+	if st.ready.Load() {
+		return
+	}
+
+	go func() {
+		start := time.Now()
+
+		var deadline <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+
+		for _, g := range st.gates {
+			select {
+			case <-g.done:
+				log.Info("Startup gate открыт", slog.String("gate", g.name))
+			case <-deadline:
+				onTimeout(st.pending())
+				return
+			}
+		}
+
+		st.ready.Store(true)
+		onReady()
+		log.Info("Запуск завершён, сервер принимает трафик", slog.Duration("duration", time.Since(start)))
+	}()
+}
+
+// httpMiddleware отвечает 503, пока запуск не завершён.
+func (st *startup) httpMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !st.ready.Load() {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "service is starting", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isSystemMethod — служебные gRPC сервисы (health, reflection): доступны во время запуска
+// и не отбрасываются при перегрузке.
+func isSystemMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/grpc.health.v1.Health/") ||
+		strings.HasPrefix(fullMethod, "/grpc.reflection.")
+}
+
+// unaryInterceptor отвечает UNAVAILABLE, пока запуск не завершён.
+func (st *startup) unaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !st.ready.Load() && !isSystemMethod(info.FullMethod) {
+			return nil, status.Error(codes.Unavailable, "service is starting")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// streamInterceptor отвечает UNAVAILABLE, пока запуск не завершён.
+func (st *startup) streamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !st.ready.Load() && !isSystemMethod(info.FullMethod) {
+			return status.Error(codes.Unavailable, "service is starting")
+		}
+		return handler(srv, ss)
+	}
+}
+
+// handler возвращает обработчик /startupz.
+// Без параметров отвечает "ok" (200) или "starting" (503),
+// с ?verbose — JSON со списком незавершённых gates.
+func (st *startup) handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		code := http.StatusOK
+		status := "ok"
+		if !st.ready.Load() {
+			code = http.StatusServiceUnavailable
+			status = "starting"
+		}
+
+		if _, verbose := req.URL.Query()["verbose"]; verbose {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(code)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"status":  status,
+				"pending": st.pending(),
+			})
+			return
+		}
+
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(status))
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStartupGates(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cache, migrations := NewStartupGate("cache"), NewStartupGate("migrations")
+	st := newStartup([]*StartupGate{cache, migrations})
+	readyCh := make(chan struct{})
+	st.wait(log, 0, func() { close(readyCh) }, func([]string) { t.Error("timeout without StartupTimeout") })
+
+	api := st.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	unary := func(method string) error {
+		_, err := st.unaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+			func(context.Context, any) (any, error) { return nil, nil })
+		return err
+	}
+
+	tests := []struct {
+		name        string
+		release     *StartupGate
+		wantReady   bool
+		wantPending []string
+	}{
+		{name: "all pending", wantPending: []string{"cache", "migrations"}},
+		{name: "one released", release: cache, wantPending: []string{"migrations"}},
+		{name: "release is idempotent", release: cache, wantPending: []string{"migrations"}},
+		{name: "all released", release: migrations, wantReady: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.release != nil {
+				tt.release.Release()
+			}
+			if tt.wantReady {
+				select {
+				case <-readyCh:
+				case <-time.After(5 * time.Second):
+					t.Fatal("onReady not called after all gates released")
+				}
+			}
+
+			wantHTTP, wantCode, wantStartupz := http.StatusServiceUnavailable, codes.Unavailable, "starting"
+			if tt.wantReady {
+				wantHTTP, wantCode, wantStartupz = http.StatusOK, codes.OK, "ok"
+			}
+
+			rec := httptest.NewRecorder()
+			st.handler()(rec, httptest.NewRequest(http.MethodGet, "/startupz", nil))
+			if rec.Code != wantHTTP || rec.Body.String() != wantStartupz {
+				t.Errorf("/startupz = %d %q, want %d %q", rec.Code, rec.Body.String(), wantHTTP, wantStartupz)
+			}
+
+			rec = httptest.NewRecorder()
+			st.handler()(rec, httptest.NewRequest(http.MethodGet, "/startupz?verbose", nil))
+			var verbose struct {
+				Pending []string `json:"pending"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &verbose); err != nil {
+				t.Fatalf("decode /startupz?verbose: %v", err)
+			}
+			if !slices.Equal(verbose.Pending, tt.wantPending) {
+				t.Errorf("pending = %v, want %v", verbose.Pending, tt.wantPending)
+			}
+
+			rec = httptest.NewRecorder()
+			api.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders", nil))
+			if rec.Code != wantHTTP {
+				t.Errorf("HTTP status = %d, want %d", rec.Code, wantHTTP)
+			}
+			if code := status.Code(unary("/orders.OrderService/Get")); code != wantCode {
+				t.Errorf("gRPC code = %v, want %v", code, wantCode)
+			}
+			if err := unary("/grpc.health.v1.Health/Check"); err != nil {
+				t.Errorf("health during startup: %v", err)
+			}
+		})
+	}
+}
+
+func TestStartupTimeout(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cache, migrations := NewStartupGate("cache"), NewStartupGate("migrations")
+	cache.Release()
+	st := newStartup([]*StartupGate{cache, migrations})
+
+	pendingCh := make(chan []string, 1)
+	st.wait(log, 20*time.Millisecond, func() { t.Error("onReady called with a pending gate") },
+		func(pending []string) { pendingCh <- pending })
+
+	select {
+	case pending := <-pendingCh:
+		if !slices.Equal(pending, []string{"migrations"}) {
+			t.Errorf("pending = %v, want [migrations]", pending)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("onTimeout not called")
+	}
+	if st.ready.Load() {
+		t.Error("startup is ready after timeout")
+	}
+}
@@ -34,12 +34,24 @@ func discoverEmbedFiles(fsys fs.FS, suffix string) []string {
 	return files
 }
 
-func (s *Server) initSwagger(log *slog.Logger) {
+func (s *Server) initSwagger(log *slog.Logger) error {
 	if s.cfg.SwaggerFS == nil {
 		log.Warn("SwaggerFS не задан, Swagger UI отключён")
-		return
+		return nil
+	}
+
+	// В single-port режиме Swagger смонтирован на HTTP gateway (см. initHTTP)
+	if s.cfg.SinglePort && s.cfg.SinglePortSwagger {
+		return nil
 	}
 
+	r, specs := s.swaggerHandler(log, "")
+	return s.serveSwagger(log, r, specs)
+}
+
+// swaggerHandler собирает роутер Swagger UI. basePath — префикс, под которым роутер
+// смонтирован (пустой для отдельного порта, "/swagger" в single-port режиме).
+func (s *Server) swaggerHandler(log *slog.Logger, basePath string) (http.Handler, int) {
 	r := chi.NewRouter()
 
 	swaggerFiles := discoverEmbedFiles(s.cfg.SwaggerFS, ".swagger.json")
@@ -89,25 +101,42 @@ func (s *Server) initSwagger(log *slog.Logger) {
 	// Главная страница
 	r.Get("/", func(w http.ResponseWriter, _ *http.Request) {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		_, _ = w.Write([]byte(buildSwaggerHTML(swaggerFiles, protoFiles)))
+		var serverURL string
+		if basePath == "" {
+			serverURL = s.swaggerServerURL()
+		}
+		_, _ = w.Write([]byte(buildSwaggerHTML(swaggerFiles, protoFiles, basePath, serverURL)))
 	})
 
+	return r, len(swaggerFiles)
+}
+
+// serveSwagger запускает Swagger UI на отдельном порту.
+func (s *Server) serveSwagger(log *slog.Logger, r http.Handler, specs int) error {
 	addr := net.JoinHostPort(s.cfg.Host, s.cfg.SwaggerPort)
 
-	s.swaggerSrv = &http.Server{
-		Addr:    addr,
-		Handler: r,
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("swagger listen %s: %w", addr, err)
+	}
+	s.addrs.Swagger = lis.Addr().String()
+	if s.cors != nil {
+		s.cors.setSwaggerAddr(s.addrs.Swagger)
 	}
 
+	s.swaggerSrv = s.cfg.newHTTPServer(s.addrs.Swagger, r)
+
 	go func() {
-		log.Info("Swagger UI запущен", slog.String("addr", addr), slog.Int("specs", len(swaggerFiles)))
-		if err := s.swaggerSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Info("Swagger UI запущен", slog.String("addr", s.addrs.Swagger), slog.Int("specs", specs))
+		if err := s.swaggerSrv.Serve(lis); err != nil && err != http.ErrServerClosed {
 			log.Error("Swagger сервер остановлен с ошибкой", slog.String("error", err.Error()))
 		}
 	}()
+
+	return nil
 }
 
-func buildSwaggerHTML(specs []string, protos []string) string {
+func buildSwaggerHTML(specs []string, protos []string, basePath, serverURL string) string {
 	if len(specs) == 0 {
 		return `<!doctype html><html><body><h2>No swagger specs found</h2></body></html>`
 	}
@@ -117,8 +146,8 @@ func buildSwaggerHTML(specs []string, protos []string) string {
 	for _, spec := range specs {
 		name := strings.TrimSuffix(filepath.Base(spec), ".swagger.json")
 		specLinks.WriteString(fmt.Sprintf(
-			`        <a href="?spec=%s" class="nav-link" data-spec="%s" data-url="/spec/%s">%s</a>`+"\n",
-			name, name, spec, name,
+			`        <a href="?spec=%s" class="nav-link" data-spec="%s" data-url="%s/spec/%s">%s</a>`+"\n",
+			name, name, basePath, spec, name,
 		))
 	}
 
@@ -265,7 +294,7 @@ func buildSwaggerHTML(specs []string, protos []string) string {
 
   <div id="content">
     <rapi-doc id="api-doc"
-      spec-url="/spec/%s"
+      spec-url="%s/spec/%s"
       theme="dark"
       render-style="read"
       show-header="false"
@@ -288,6 +317,11 @@ func buildSwaggerHTML(specs []string, protos []string) string {
     const navLinks = document.querySelectorAll('.nav-link[data-spec]');
 
     const protoFiles = %s;
+    const basePath = "%s";
+
+    // «Try it out» против gateway на другом порту (WithSwaggerCORS)
+    const serverURL = "%s".replace('{host}', location.hostname);
+    if (serverURL) apiDoc.setAttribute('server-url', serverURL);
 
     // --- File tree builder ---
     function buildTree(paths) {
@@ -320,7 +354,7 @@ func buildSwaggerHTML(specs []string, protos []string) string {
       if (node._files) {
         node._files.sort((a, b) => a.name.localeCompare(b.name));
         node._files.forEach(f => {
-          html += '<div class="tree-file" style="--depth:' + (depth) + '" data-path="' + f.path + '" data-url="/proto/' + f.path + '">';
+          html += '<div class="tree-file" style="--depth:' + (depth) + '" data-path="' + f.path + '" data-url="' + basePath + '/proto/' + f.path + '">';
           html += '<span class="icon">&#9679;</span>' + f.name;
           html += '</div>';
         });
@@ -426,13 +460,15 @@ func buildSwaggerHTML(specs []string, protos []string) string {
     route();
   </script>
 </body>
-</html>`, specLinks.String(), specs[0], protoJSON)
+</html>`, specLinks.String(), basePath, specs[0], protoJSON, basePath, serverURL)
 }
 
 func (s *Server) stopSwagger(ctx context.Context, log *slog.Logger) error {
 	if s.swaggerSrv != nil {
 		log.Info("Swagger сервер завершает работу...")
-		return s.swaggerSrv.Shutdown(ctx)
+		if err := shutdownHTTPServer(ctx, s.swaggerSrv); err != nil {
+			return fmt.Errorf("swagger shutdown: %w", err)
+		}
 	}
 	return nil
 }
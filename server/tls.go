@@ -0,0 +1,211 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultTLSReloadInterval = 10 * time.Second
+
+// TLSConfig — настройки TLS для одного listener (gRPC, HTTP gateway или debug).
+type TLSConfig struct {
+	// CertFile и KeyFile — пути к сертификату и приватному ключу в PEM.
+	// Файлы перечитываются при изменении на диске (ротация сертификатов без рестарта).
+	CertFile string
+	KeyFile  string
+	// ClientCAFile — PEM с CA для проверки клиентских сертификатов (mTLS). Перечитывается при изменении.
+	ClientCAFile string
+	// ClientAuth — политика проверки клиентских сертификатов при заданном ClientCAFile.
+	// По умолчанию tls.RequireAndVerifyClientCert; tls.VerifyClientCertIfGiven — проверять, только если клиент его прислал.
+	ClientAuth tls.ClientAuthType
+	// ReloadInterval — как часто проверять изменения файлов на диске. 0 — 10s.
+	ReloadInterval time.Duration
+	// Config — готовый tls.Config (напр. из secret manager). Если задан, остальные поля игнорируются.
+	Config *tls.Config `secret:"true"`
+}
+
+func (c *TLSConfig) validate() error {
+	if c.Config != nil {
+		return nil
+	}
+	if c.CertFile == "" || c.KeyFile == "" {
+		return errors.New("CertFile and KeyFile are required when Config is nil")
+	}
+	return nil
+}
+
+// build создаёт tls.Config. Сертификат и CA загружаются сразу, чтобы ошибка была видна при старте.
+func (c *TLSConfig) build(log *slog.Logger) (*tls.Config, error) {
+	if c.Config != nil {
+		return c.Config.Clone(), nil
+	}
+
+	interval := c.ReloadInterval
+	if interval <= 0 {
+		interval = defaultTLSReloadInterval
+	}
+
+	r := &certReloader{
+		certFile: c.CertFile,
+		keyFile:  c.KeyFile,
+		caFile:   c.ClientCAFile,
+		interval: interval,
+		log:      log,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.getCertificate,
+	}
+
+	if c.ClientCAFile != "" {
+		// Цепочку клиента проверяем сами в VerifyConnection против текущего (перечитываемого) CA pool,
+		// поэтому стандартной проверке tls оставляем только запрос сертификата.
+		switch c.ClientAuth {
+		case tls.VerifyClientCertIfGiven, tls.RequestClientCert:
+			tlsCfg.ClientAuth = tls.RequestClientCert
+		default:
+			tlsCfg.ClientAuth = tls.RequireAnyClientCert
+		}
+		tlsCfg.VerifyConnection = r.verifyClient
+	}
+
+	return tlsCfg, nil
+}
+
+// certReloader хранит текущие сертификат и CA pool и перечитывает файлы,
+// если их время модификации изменилось. Проверка файлов — не чаще interval.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	interval time.Duration
+	log      *slog.Logger
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+	checkedAt time.Time
+}
+
+// reload загружает сертификат, ключ и CA с диска.
+func (r *certReloader) reload() error {
+	modTimes := make(map[string]time.Time)
+	for _, f := range []string{r.certFile, r.keyFile, r.caFile} {
+		if f == "" {
+			continue
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", f, err)
+		}
+		modTimes[f] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("read client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("client CA %s: no certificates found", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = pool
+	r.modTimes = modTimes
+	r.checkedAt = time.Now()
+	r.mu.Unlock()
+
+	return nil
+}
+
+// maybeReload перечитывает файлы, если с последней проверки прошло больше interval
+// и хотя бы один файл изменился. При ошибке продолжает работать со старыми данными.
+func (r *certReloader) maybeReload() {
+	r.mu.Lock()
+	if time.Since(r.checkedAt) < r.interval {
+		r.mu.Unlock()
+		return
+	}
+	r.checkedAt = time.Now()
+
+	changed := false
+	for f, mod := range r.modTimes {
+		info, err := os.Stat(f)
+		if err == nil && !info.ModTime().Equal(mod) {
+			changed = true
+			break
+		}
+	}
+	r.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if err := r.reload(); err != nil {
+		r.log.Error("Не удалось перечитать TLS сертификаты, используются прежние",
+			slog.String("cert", r.certFile),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+	r.log.Info("TLS сертификаты перечитаны", slog.String("cert", r.certFile))
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.maybeReload()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// verifyClient проверяет цепочку клиентского сертификата против текущего CA pool.
+func (r *certReloader) verifyClient(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		// Обязательность сертификата уже проверил tls (RequireAnyClientCert)
+		return nil
+	}
+
+	r.maybeReload()
+
+	r.mu.RLock()
+	roots := r.clientCAs
+	r.mu.RUnlock()
+
+	intermediates := x509.NewCertPool()
+	for _, c := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("verify client certificate: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert — сертификат с ключом, подписанный parent (или самоподписанный CA при parent == nil).
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+		tmpl.ExtKeyUsage = nil
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create certificate %s: %v", cn, err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate %s: %v", cn, err)
+	}
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+}
+
+func (c *testCert) keyPEM(t *testing.T) []byte {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+// writeFileAt пишет файл и сдвигает mtime, чтобы изменение было видно даже на ФС с грубым временем.
+func writeFileAt(t *testing.T, path string, data []byte, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatalf("chtimes %s: %v", path, err)
+	}
+}
+
+// TestCertReloaderRotation проверяет, что после ротации файлов на диске новый handshake получает
+// новый сертификат сервера, а клиентский сертификат проверяется по перечитанному CA.
+func TestCertReloaderRotation(t *testing.T) {
+	ca1 := newTestCert(t, "ca-1", nil, 0)
+	ca2 := newTestCert(t, "ca-2", nil, 0)
+	server1 := newTestCert(t, "server-1", ca1, x509.ExtKeyUsageServerAuth)
+	server2 := newTestCert(t, "server-2", ca2, x509.ExtKeyUsageServerAuth)
+	client1 := newTestCert(t, "client-1", ca1, x509.ExtKeyUsageClientAuth)
+	client2 := newTestCert(t, "client-2", ca2, x509.ExtKeyUsageClientAuth)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	// rotate пишет сертификат сервера и клиентский CA с новым mtime
+	rotate := func(server, ca *testCert, mtime time.Time) {
+		writeFileAt(t, certFile, server.certPEM(), mtime)
+		writeFileAt(t, keyFile, server.keyPEM(t), mtime)
+		writeFileAt(t, caFile, ca.certPEM(), mtime)
+	}
+	start := time.Now().Add(-time.Minute)
+	rotate(server1, ca1, start)
+
+	const interval = 10 * time.Millisecond
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tlsCfg, err := (&TLSConfig{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ClientCAFile:   caFile,
+		ReloadInterval: interval,
+	}).build(log)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", tlsCfg)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = lis.Close() })
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if err := conn.(*tls.Conn).Handshake(); err == nil {
+					_, _ = conn.Write([]byte("ok"))
+				}
+			}()
+		}
+	}()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca1.cert)
+	roots.AddCert(ca2.cert)
+
+	tests := []struct {
+		name   string
+		rotate bool
+		client *testCert
+		// wantLeaf — CN сертификата сервера; пусто — handshake должен быть отклонён
+		wantLeaf string
+	}{
+		{name: "before rotation", client: client1, wantLeaf: "server-1"},
+		{name: "new client cert rejected before rotation", client: client2},
+		{name: "new leaf and CA after rotation", rotate: true, client: client2, wantLeaf: "server-2"},
+		{name: "old client cert rejected after rotation", client: client1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.rotate {
+				rotate(server2, ca2, start.Add(time.Second))
+				time.Sleep(2 * interval)
+			}
+
+			conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", lis.Addr().String(), &tls.Config{
+				ServerName:   "localhost",
+				RootCAs:      roots,
+				Certificates: []tls.Certificate{tt.client.tlsCertificate()},
+			})
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+			// В TLS 1.3 отказ в клиентском сертификате клиент видит при первом чтении
+			buf := make([]byte, 2)
+			_, err = io.ReadFull(conn, buf)
+			if tt.wantLeaf == "" {
+				if err == nil {
+					t.Fatal("handshake with a certificate from another CA succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if cn := conn.ConnectionState().PeerCertificates[0].Subject.CommonName; cn != tt.wantLeaf {
+				t.Errorf("server certificate = %s, want %s", cn, tt.wantLeaf)
+			}
+		})
+	}
+}